@@ -16,19 +16,40 @@ func (fm *FuncMaker) getFuncName(dstType, srcType types.Type) (string, error) {
 		err = errors.New("cannot type")
 	}
 
+	srcName = funcTypeName(srcName)
+	dstName = funcTypeName(dstName)
+
+	if FuncName != nil {
+		if err != nil {
+			return "", err
+		}
+		return execNameTemplate(FuncName, NameData{Src: fm.nameInfo(srcType), Dst: fm.nameInfo(dstType), Srcs: []NameInfo{fm.nameInfo(srcType)}, Dsts: []NameInfo{fm.nameInfo(dstType)}, Into: Mode == ModeInto, Apply: Mode == ModeApply})
+	}
+	if DeepCopy && Mode != ModeApply && types.Identical(dstType, srcType) {
+		return applyVisibility(deepCopyName(srcName)), err
+	}
+	return applyVisibility(fmt.Sprintf("%s%s%s%s", funcPrefix(), srcName, funcVerb(), dstName)), err
+}
+
+// funcTypeName 関数名に使えるように、型名の . [] * map[K] [N] を置き換える。
+func funcTypeName(name string) string {
 	re := regexp.MustCompile(`\.`)
-	srcName = string(re.ReplaceAll([]byte(srcName), []byte("")))
-	dstName = string(re.ReplaceAll([]byte(dstName), []byte("")))
+	name = string(re.ReplaceAll([]byte(name), []byte("")))
 
 	re = regexp.MustCompile(`\[\]`)
-	srcName = string(re.ReplaceAll([]byte(srcName), []byte("S")))
-	dstName = string(re.ReplaceAll([]byte(dstName), []byte("S")))
+	name = string(re.ReplaceAll([]byte(name), []byte("S")))
 
 	re = regexp.MustCompile(`\*`)
-	srcName = string(re.ReplaceAll([]byte(srcName), []byte("P")))
-	dstName = string(re.ReplaceAll([]byte(dstName), []byte("P")))
+	name = string(re.ReplaceAll([]byte(name), []byte("P")))
+
+	re = regexp.MustCompile(`map\[`)
+	name = string(re.ReplaceAll([]byte(name), []byte("Map")))
+
+	re = regexp.MustCompile(`\[(\d+)\]`)
+	name = string(re.ReplaceAll([]byte(name), []byte("A$1")))
 
-	return fmt.Sprintf("Conv%sTo%s", srcName, dstName), err
+	re = regexp.MustCompile(`[\[\]]`)
+	return string(re.ReplaceAll([]byte(name), []byte("")))
 }
 
 func (fm *FuncMaker) isAlreadyExist(funcName string) bool {
@@ -98,14 +119,56 @@ func (fm *FuncMaker) formatPkgString(str string) string {
 }
 
 func (fm *FuncMaker) formatPkgType(t types.Type) (string, error) {
+	fm.importType(t)
 	if namedT, ok := t.(*types.Named); ok {
 		if !fm.typeNameVisiable(namedT.Obj()) {
 			return "", errors.New("not exported")
 		}
 	}
+	if _, ok := t.(*types.Map); ok {
+		if !fm.typeVisible(t) {
+			return "", errors.New("not exported")
+		}
+		return types.TypeString(t, fm.qualifier), nil
+	}
 	return fm.formatPkgString(t.String()), nil
 }
 
+// importType 型が参照するパッケージを import する
+func (fm *FuncMaker) importType(t types.Type) {
+	switch t := t.(type) {
+	case *types.Named:
+		fm.importPkg(t.Obj().Pkg())
+	case *types.Pointer:
+		fm.importType(t.Elem())
+	case *types.Slice:
+		fm.importType(t.Elem())
+	case *types.Array:
+		fm.importType(t.Elem())
+	case *types.Map:
+		fm.importType(t.Key())
+		fm.importType(t.Elem())
+	case *types.Struct:
+		for i := 0; i < t.NumFields(); i++ {
+			fm.importType(t.Field(i).Type())
+		}
+	}
+}
+
+// qualifier 出力するパッケージの型にはパッケージ名を付けない
+func (fm *FuncMaker) qualifier(pkg *types.Package) string {
+	if fm.samePkg(pkg) {
+		return ""
+	}
+	return pkg.Name()
+}
+
+func isNamedPair(dst, src types.Type) bool {
+	_, dok := dst.(*types.Named)
+	_, sok := src.(*types.Named)
+	return dok && sok
+}
+
 // 無限ループを防ぐ
 func checkHistory(dst, src types.Type, history [][2]types.Type) bool {
 	for _, his := range history {
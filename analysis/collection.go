@@ -0,0 +1,158 @@
+package analysis
+
+import (
+	"fmt"
+	"go/types"
+)
+
+// NilPolicy nil、空のスライス・マップの扱い
+type NilPolicy int
+
+const (
+	// NilAllocate 常に make する。nil は空になる
+	NilAllocate NilPolicy = iota
+	// NilPreserve src が nil であれば、dst も nil のまま
+	NilPreserve
+	// NilOmitEmpty src が空であれば、dst は nil のまま
+	NilOmitEmpty
+	// NilDefault 異なる型は NilAllocate と同じ。同じ型のスライス・マップはそのまま代入する
+	NilDefault
+)
+
+// NilCollection スライス・マップの nil の扱い
+var NilCollection = NilDefault
+
+// ParseNilPolicy parses the value of the -nil flag.
+func ParseNilPolicy(s string) (NilPolicy, error) {
+	switch s {
+	case "":
+		return NilDefault, nil
+	case "allocate":
+		return NilAllocate, nil
+	case "preserve":
+		return NilPreserve, nil
+	case "omitempty":
+		return NilOmitEmpty, nil
+	}
+	return NilDefault, fmt.Errorf("unknown nil policy %q", s)
+}
+
+// collectionGuard スライス・マップを make する条件
+func (fm *FuncMaker) collectionGuard(t types.Type, srcSelector, index string) (string, bool) {
+	switch NilCollection {
+	case NilPreserve:
+		return fmt.Sprintf("%s != nil", srcSelector), true
+	case NilOmitEmpty:
+		return fmt.Sprintf("len(%s) > 0", srcSelector), true
+	}
+	return fm.applyGuard(t, srcSelector, index)
+}
+
+// writeCollection 条件を満たすときだけ、スライス・マップを書き込む。
+// ModeInto の場合は、条件を満たさなければ nil にする。
+func (fm *FuncMaker) writeCollection(t types.Type, dstSelector, srcSelector, index string, write func() bool) bool {
+	guard, ok := fm.collectionGuard(t, srcSelector, index)
+	id := 0
+	if ok {
+		id = fm.openGuard(guard)
+	}
+	written := write()
+	if ok {
+		if Mode == ModeInto && (NilCollection == NilPreserve || NilCollection == NilOmitEmpty) {
+			fm.closeGuardElse(id, fmt.Sprintf("%s = nil\n", dstSelector))
+		} else {
+			fm.closeGuard(id)
+		}
+	}
+	return written
+}
+
+// isCollection スライスかマップか
+func isCollection(t types.Type) bool {
+	switch t.Underlying().(type) {
+	case *types.Slice, *types.Map:
+		return true
+	}
+	return false
+}
+
+// identicalCollection 同じ型のスライス・マップを代入する。
+// NilAllocate の場合は、src が nil であれば空のスライス・マップにする。
+func (fm *FuncMaker) identicalCollection(dst, src types.Type, assign, dstSelector, srcSelector, index string) bool {
+	dt, err := fm.formatPkgType(dst)
+	allocate := NilCollection == NilAllocate && err == nil
+	fm.writeCollection(src, dstSelector, srcSelector, index, func() bool {
+		if allocate {
+			fmt.Fprintf(fm.buf, "if %s == nil {\n%s = %s{}\n} else {\n%s}\n", srcSelector, dstSelector, dt, assign)
+		} else {
+			fmt.Fprint(fm.buf, assign)
+		}
+		return true
+	})
+	fm.dstWrittenSelector[dstSelector] = struct{}{}
+	return true
+}
+
+// mapAndMap key と要素を一時変数に変換してから代入する。
+func (fm *FuncMaker) mapAndMap(dstT, srcT TypeMap, dstSelector, srcSelector, index string, history [][2]types.Type) bool {
+	prevIndex := index
+	index = nextIndex(index)
+
+	vars := *fm.tmpVars
+	written := fm.deferWrite(func(tmpFm *FuncMaker) bool {
+		dt, err := tmpFm.formatPkgType(dstT.typ)
+		if err != nil {
+			return false
+		}
+
+		written := tmpFm.writeCollection(srcT.typ, dstSelector, srcSelector, prevIndex, func() bool {
+			if useCvtUtil() && tmpFm.mapCvtUtil(dstT, srcT, dstSelector, srcSelector, history) {
+				return true
+			}
+			fmt.Fprintf(tmpFm.buf, "%s = make(%s, len(%s))\n", dstSelector, dt, srcSelector)
+			elem := srcSelector + "[" + index + "]"
+			if inPlace() {
+				// マップの要素はアドレスを取れないので、コピーしてから渡す
+				elem = tmpFm.newVar()
+				fmt.Fprintf(tmpFm.buf, "for %s, %s := range %s {\n", index, elem, srcSelector)
+			} else {
+				fmt.Fprintf(tmpFm.buf, "for %s := range %s {\n", index, srcSelector)
+			}
+			defer fmt.Fprintf(tmpFm.buf, "}\n")
+
+			key, ok := tmpFm.mapElem(dstT.typ.Key(), srcT.typ.Key(), index, index, history)
+			if !ok {
+				return false
+			}
+			value, ok := tmpFm.mapElem(dstT.typ.Elem(), srcT.typ.Elem(), elem, index, history)
+			if !ok {
+				return false
+			}
+			fmt.Fprintf(tmpFm.buf, "%s[%s] = %s\n", dstSelector, key, value)
+			return true
+		})
+		if written {
+			tmpFm.dstWrittenSelector[dstSelector] = struct{}{}
+		}
+		return written
+	})
+	if !written {
+		*fm.tmpVars = vars
+	}
+	return written
+}
+
+// mapElem マップの key または要素を変換した式。
+// 同じ型であればそのまま、異なる型であれば一時変数に変換する。
+func (fm *FuncMaker) mapElem(dst, src types.Type, srcSelector, index string, history [][2]types.Type) (string, bool) {
+	if types.Identical(dst, src) && !fm.deepCopy(src) {
+		return srcSelector, true
+	}
+	dt, err := fm.formatPkgType(dst)
+	if err != nil {
+		return "", false
+	}
+	v := fm.newVar()
+	fmt.Fprintf(fm.buf, "var %s %s\n", v, dt)
+	return v, fm.makeFunc(Type{typ: dst}, Type{typ: src}, v, srcSelector, index, history)
+}
@@ -0,0 +1,121 @@
+package analysis
+
+import (
+	"bytes"
+	"fmt"
+	"go/types"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// CvtUtil スライス・マップ・ポインタを、ループの代わりに cvtutil の関数で変換する
+var CvtUtil = false
+
+// CvtUtilPath cvtutil の import path
+const CvtUtilPath = "github.com/fuji8/gotypeconverter/cvtutil"
+
+// useCvtUtil -mode return で、visited map を使わない場合のみ
+func useCvtUtil() bool {
+	return CvtUtil && Mode == ModeReturn && !Graph
+}
+
+// Imports 生成した関数が使う import path。
+// goimports で追加できないもの、生成した時点で解決できないものを含む
+func (fm *FuncMaker) Imports() []string {
+	paths := make([]string, 0, len(fm.imports))
+	for path := range fm.imports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// importPkg 生成した関数が使う、他のパッケージを import する
+func (fm *FuncMaker) importPkg(pkg *types.Package) {
+	if pkg == nil || fm.samePkg(pkg) {
+		return
+	}
+	fm.imports[importPath(pkg.Path())] = struct{}{}
+}
+
+// importPath vendor 以下のパッケージは、vendor を除いたパスで import する
+func importPath(path string) string {
+	if i := strings.LastIndex(path, "/vendor/"); i >= 0 {
+		return path[i+len("/vendor/"):]
+	}
+	return strings.TrimPrefix(path, "vendor/")
+}
+
+// cvtutil cvtutil の関数を呼び出す式
+func (fm *FuncMaker) cvtutil(name string, args ...string) string {
+	fm.imports[CvtUtilPath] = struct{}{}
+	return fmt.Sprintf("cvtutil.%s(%s)", name, strings.Join(args, ", "))
+}
+
+var simpleCallRe = regexp.MustCompile(`^d = ([\w.]+)\(s\)\n$`)
+
+// elemFunc 要素を変換する関数。d = ConvXToY(s) だけであれば ConvXToY、
+// それ以外は func(s X) (d Y) { ... } にする。
+func (fm *FuncMaker) elemFunc(dst, src types.Type, history [][2]types.Type) (string, bool) {
+	dt, err := fm.formatPkgType(dst)
+	if err != nil {
+		return "", false
+	}
+	st, err := fm.formatPkgType(src)
+	if err != nil {
+		return "", false
+	}
+
+	tmpFm := *fm
+	tmpFm.buf = new(bytes.Buffer)
+	tmpFm.dstWrittenSelector = map[string]struct{}{}
+	// 関数リテラルの中の if 文では、ポインタを確保しない
+	tmpFm.lazy = nil
+	// 関数リテラルからは error を返せないので、ループにする
+	tmpFm.fallible = new(bool)
+	if !tmpFm.makeFunc(Type{typ: dst}, Type{typ: src}, "d", "s", "", history) || *tmpFm.fallible {
+		return "", false
+	}
+	body := tmpFm.buf.String()
+	if m := simpleCallRe.FindStringSubmatch(body); m != nil {
+		return m[1], true
+	}
+	return fmt.Sprintf("func(s %s) (d %s) {\n%sreturn\n}", st, dt, body), true
+}
+
+// sliceCvtUtil dst = cvtutil.MapSlice(src, f)
+func (fm *FuncMaker) sliceCvtUtil(dstT, srcT TypeSlice, dstSelector, srcSelector string, history [][2]types.Type) bool {
+	f, ok := fm.elemFunc(dstT.typ.Elem(), srcT.typ.Elem(), history)
+	if !ok {
+		return false
+	}
+	fmt.Fprintf(fm.buf, "%s = %s\n", dstSelector, fm.cvtutil("MapSlice", srcSelector, f))
+	return true
+}
+
+// mapCvtUtil dst = cvtutil.MapMap(src, f)。key が同じ型のときのみ
+func (fm *FuncMaker) mapCvtUtil(dstT, srcT TypeMap, dstSelector, srcSelector string, history [][2]types.Type) bool {
+	if !types.Identical(dstT.typ.Key(), srcT.typ.Key()) {
+		return false
+	}
+	f, ok := fm.elemFunc(dstT.typ.Elem(), srcT.typ.Elem(), history)
+	if !ok {
+		return false
+	}
+	fmt.Fprintf(fm.buf, "%s = %s\n", dstSelector, fm.cvtutil("MapMap", srcSelector, f))
+	return true
+}
+
+// pointerCvtUtil dst = cvtutil.MapPtr(src, f)
+func (fm *FuncMaker) pointerCvtUtil(dstT, srcT TypePointer, dstSelector, srcSelector string, history [][2]types.Type) bool {
+	return fm.deferWrite(func(tmpFm *FuncMaker) bool {
+		f, ok := tmpFm.elemFunc(dstT.typ.Elem(), srcT.typ.Elem(), history)
+		if !ok {
+			return false
+		}
+		fmt.Fprintf(tmpFm.buf, "%s = %s\n", dstSelector, tmpFm.cvtutil("MapPtr", srcSelector, f))
+		tmpFm.dstWrittenSelector[dstSelector] = struct{}{}
+		return true
+	})
+}
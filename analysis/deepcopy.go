@@ -0,0 +1,74 @@
+package analysis
+
+import (
+	"fmt"
+	"go/types"
+)
+
+// DeepCopy 同じ型でも代入せずに、スライス・マップ・ポインタ・配列を作り直してコピーする
+var DeepCopy = false
+
+// deepCopy 同じ型の代入で、スライス・マップ・ポインタを共有してしまうか
+func (fm *FuncMaker) deepCopy(t types.Type) bool {
+	return DeepCopy && fm.shares(t, map[*types.Named]bool{})
+}
+
+// shares 見えないフィールドを持つ struct は、フィールドごとにコピーできないので代入する。
+// interface, func, chan も代入する。
+func (fm *FuncMaker) shares(t types.Type, seen map[*types.Named]bool) bool {
+	switch t := t.(type) {
+	case *types.Named:
+		if seen[t] {
+			return false
+		}
+		seen[t] = true
+		return fm.shares(t.Underlying(), seen)
+	case *types.Pointer, *types.Slice, *types.Map:
+		return true
+	case *types.Array:
+		return fm.shares(t.Elem(), seen)
+	case *types.Struct:
+		for i := 0; i < t.NumFields(); i++ {
+			if !fm.varVisiable(t.Field(i)) {
+				return false
+			}
+		}
+		for i := 0; i < t.NumFields(); i++ {
+			if fm.shares(t.Field(i).Type(), seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// deepCopyName 同じ型をコピーする関数名。DeepCopyX, DeepCopyIntoX
+func deepCopyName(typeName string) string {
+	if Mode == ModeInto {
+		return "DeepCopyInto" + typeName
+	}
+	return "DeepCopy" + typeName
+}
+
+// arrayAndArray 長さが同じ配列の要素ごとに変換する。
+func (fm *FuncMaker) arrayAndArray(dstT, srcT TypeArray, dstSelector, srcSelector, index string, history [][2]types.Type) bool {
+	if dstT.typ.Len() != srcT.typ.Len() {
+		return false
+	}
+	index = nextIndex(index)
+
+	return fm.deferWrite(func(tmpFm *FuncMaker) bool {
+		fmt.Fprintf(tmpFm.buf, "for %s := range %s {\n", index, srcSelector)
+		written := tmpFm.makeFunc(Type{typ: dstT.typ.Elem()}, Type{typ: srcT.typ.Elem()},
+			dstSelector+"["+index+"]",
+			srcSelector+"["+index+"]",
+			index,
+			history,
+		)
+		fmt.Fprintf(tmpFm.buf, "}\n")
+		if written {
+			tmpFm.dstWrittenSelector[dstSelector] = struct{}{}
+		}
+		return written
+	})
+}
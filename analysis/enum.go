@@ -0,0 +1,157 @@
+package analysis
+
+import (
+	"fmt"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+var (
+	// EnumRenames 定数名の対応 (src の定数名 -> dst の定数名)
+	EnumRenames = map[string]string{}
+	// EnumUnknown 対応する定数が無いときに代入する dst の定数名。
+	// EnumUnknownError のときは、対応しない定数があればエラーにし、
+	// 定数以外の値は生成した関数がエラーを返す。
+	EnumUnknown = ""
+)
+
+// EnumUnknownError EnumUnknown に指定すると、対応しない定数をエラーにし、定数以外の値は実行時にエラーを返す
+const EnumUnknownError = "error"
+
+// enumConsts t の型を持つパッケージレベルの定数を、名前順で返す。
+func (fm *FuncMaker) enumConsts(t *types.Named) []*types.Const {
+	pkg := t.Obj().Pkg()
+	if pkg == nil {
+		return nil
+	}
+	consts := make([]*types.Const, 0)
+	for _, name := range pkg.Scope().Names() {
+		c, ok := pkg.Scope().Lookup(name).(*types.Const)
+		if !ok || !types.Identical(c.Type(), t) {
+			continue
+		}
+		if !fm.samePkg(c.Pkg()) && !c.Exported() {
+			continue
+		}
+		consts = append(consts, c)
+	}
+	return consts
+}
+
+// enumName 定数名から型名を取り除く (StatusDraft -> Draft)
+func enumName(c *types.Const, t *types.Named) string {
+	typeName := t.Obj().Name()
+	name := c.Name()
+	if len(name) > len(typeName) {
+		if strings.HasPrefix(name, typeName) {
+			return name[len(typeName):]
+		}
+		if strings.HasSuffix(name, typeName) {
+			return name[:len(name)-len(typeName)]
+		}
+	}
+	return name
+}
+
+func (fm *FuncMaker) formatConst(c *types.Const) string {
+	fm.importPkg(c.Pkg())
+	return fm.formatPkgString(c.Pkg().Path() + "." + c.Name())
+}
+
+// enumAndEnum 定数名が対応するように switch で変換する。
+// 対応する定数が一つも無いときは false を返し、キャストなどで変換する。
+func (fm *FuncMaker) enumAndEnum(dstT, srcT TypeNamed, dstSelector, srcSelector string) bool {
+	dstConsts := fm.enumConsts(dstT.typ)
+	srcConsts := fm.enumConsts(srcT.typ)
+	if len(dstConsts) == 0 || len(srcConsts) == 0 {
+		return false
+	}
+
+	findDst := func(src *types.Const) *types.Const {
+		if rename, ok := EnumRenames[src.Name()]; ok {
+			for _, d := range dstConsts {
+				if d.Name() == rename {
+					return d
+				}
+			}
+			fm.addError(fmt.Errorf("%s: %s is not a constant of %s", src.Name(), rename, dstT.typ))
+			return nil
+		}
+		for _, exact := range []bool{true, false} {
+			for _, d := range dstConsts {
+				if matchName(enumName(d, dstT.typ), enumName(src, srcT.typ), exact) {
+					return d
+				}
+			}
+		}
+		return nil
+	}
+
+	var unknown *types.Const
+	if EnumUnknown != "" && EnumUnknown != EnumUnknownError {
+		for _, d := range dstConsts {
+			if d.Name() == EnumUnknown {
+				unknown = d
+			}
+		}
+		if unknown == nil {
+			fm.addError(fmt.Errorf("%s is not a constant of %s", EnumUnknown, dstT.typ))
+		}
+	}
+
+	// 先に対応を求め、対応する定数が一つも無ければ switch にしない
+	type enumCase struct{ src, dst *types.Const }
+	cases := make([]enumCase, 0, len(srcConsts))
+	seen := make([]constant.Value, 0, len(srcConsts))
+	unmapped := make([]string, 0)
+NEXT:
+	for _, s := range srcConsts {
+		// 同じ値の定数は、最初のものだけ使う
+		for _, v := range seen {
+			if constant.Compare(v, token.EQL, s.Val()) {
+				continue NEXT
+			}
+		}
+		seen = append(seen, s.Val())
+
+		d := findDst(s)
+		if d == nil {
+			unmapped = append(unmapped, s.Name())
+			continue
+		}
+		cases = append(cases, enumCase{s, d})
+	}
+	if len(cases) == 0 {
+		return false
+	}
+
+	fmt.Fprintf(fm.buf, "switch %s {\n", srcSelector)
+	for _, c := range cases {
+		fmt.Fprintf(fm.buf, "case %s:\n%s = %s\n", fm.formatConst(c.src), dstSelector, fm.formatConst(c.dst))
+	}
+	switch {
+	case unknown != nil:
+		fmt.Fprintf(fm.buf, "default:\n%s = %s\n", dstSelector, fm.formatConst(unknown))
+	case EnumUnknown == EnumUnknownError:
+		// 定数以外の値は、実行時にエラーを返す
+		fmt.Fprintf(fm.buf, "default:\nerr = fmt.Errorf(\"%%v is not a constant of %s\", %s)\nreturn\n",
+			fm.formatPkgString(dstT.typ.String()), srcSelector)
+		*fm.fallible = true
+	case types.ConvertibleTo(srcT.typ, dstT.typ):
+		// 定数以外の値は、ゼロ値にせずキャストする
+		fm.importType(dstT.typ)
+		fmt.Fprintf(fm.buf, "default:\n%s = %s(%s)\n",
+			dstSelector, fm.formatPkgString(dstT.typ.String()), srcSelector)
+	}
+	fmt.Fprintf(fm.buf, "}\n")
+
+	if EnumUnknown == EnumUnknownError && len(unmapped) != 0 {
+		fm.addError(fmt.Errorf("%s -> %s: no constant for %s",
+			srcT.typ, dstT.typ, strings.Join(unmapped, ", ")))
+	}
+
+	fm.dstWrittenSelector[dstSelector] = struct{}{}
+	return true
+}
@@ -0,0 +1,41 @@
+package analysis
+
+import (
+	"errors"
+	"fmt"
+)
+
+// writeErrCheck err を代入する文 stmt を書き込み、err があれば返す。
+// 書き込んだ関数は error も返すようになる
+func (fm *FuncMaker) writeErrCheck(stmt string) {
+	fmt.Fprintf(fm.buf, "if %s; err != nil {\nreturn\n}\n", stmt)
+	*fm.fallible = true
+}
+
+// call conv を呼び出す文。conv が error を返す場合は、呼び出す関数も error を返す。
+// 自身の呼び出し、visited map を渡す呼び出しであれば記録する
+func (fm *FuncMaker) call(conv converter, dstSelector, srcSelector string) string {
+	if fm.names.fallible[conv.key()] {
+		conv.err = true
+		*fm.fallible = true
+	}
+	if conv.key() == fm.funcName {
+		*fm.recursive = true
+	}
+	if conv.graph {
+		*fm.visited = true
+	}
+	return conv.call(dstSelector, srcSelector)
+}
+
+// replaceHeader buf の header を、error も返す関数の header にする
+func (fm *FuncMaker) replaceHeader(headerStart, bodyStart int, conv converter, dstName, srcName string) {
+	header, _, _ := conv.header(dstName, srcName)
+	body := append([]byte(nil), fm.buf.Bytes()[bodyStart:]...)
+	fm.buf.Truncate(headerStart)
+	fm.buf.WriteString(header)
+	fm.buf.Write(body)
+}
+
+// errGraph visited map を使う関数は error を返せない
+var errGraph = errors.New("-graph cannot be used with conversions that return errors")
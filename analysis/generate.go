@@ -7,7 +7,7 @@ import (
 )
 
 func selectorGen(selector string, field *types.Var) string {
-	return fmt.Sprintf("%s.%s", selector, field.Name())
+	return fmt.Sprintf("%s.%s", derefSelector(selector), field.Name())
 }
 
 // FuncMaker generate function
@@ -22,6 +22,40 @@ type FuncMaker struct {
 
 	// 同じselectorに対して書き込むのは一回のみ
 	dstWrittenSelector map[string]struct{}
+
+	// 生成中のエラー。全ての FuncMaker で共有する
+	errs *[]error
+	// 関数内の一時変数の数
+	tmpVars *int
+
+	// 見えないフィールドを読み書きする関数。全ての FuncMaker で共有する
+	helpers map[*types.Package]map[string]string
+	// 対応するフィールドが見えなかったもの。全ての FuncMaker で共有する
+	privates map[string]string
+	// 生成する関数の名前。全ての FuncMaker で共有する
+	names *nameTable
+	// goimports で追加できない import path。全ての FuncMaker で共有する
+	imports map[string]struct{}
+	// ModeApply で、ゼロ値の確認をしない
+	noGuard bool
+	// 書き込み中のフィールドに指定された、スライスとの変換方法
+	slice fieldSlice
+	// 書き込み中のフィールドに指定された、time.Time time.Duration の変換規則
+	time fieldTime
+	// 関数の dst の selector。ここでのみ named type を展開する
+	rootDst string
+	// 複数の dst を返す関数で、rootDst に書き込んだ src のフィールド
+	feeds *[]string
+	// Collections で生成する関数。生成を始めた関数と同様に、外から呼び出す
+	collection bool
+	// 関数が error も返す。同じ関数の FuncMaker で共有する
+	fallible *bool
+	// 関数が自身を呼び出す。同じ関数の FuncMaker で共有する
+	recursive *bool
+	// 関数が visited map を渡して呼び出す。同じ関数の FuncMaker で共有する
+	visited *bool
+	// ポインタを確保する文の中で書き込んだ if 文
+	lazy *lazyGuards
 }
 
 func (fm *FuncMaker) Pkg() *types.Package {
@@ -33,6 +67,15 @@ func InitFuncMaker(pkg *types.Package) *FuncMaker {
 		buf:                new(bytes.Buffer),
 		pkg:                pkg,
 		dstWrittenSelector: map[string]struct{}{},
+		errs:               new([]error),
+		tmpVars:            new(int),
+		helpers:            map[*types.Package]map[string]string{},
+		privates:           map[string]string{},
+		names:              newNameTable(),
+		imports:            map[string]struct{}{},
+		fallible:           new(bool),
+		recursive:          new(bool),
+		visited:            new(bool),
 	}
 	tmp := make([]*FuncMaker, 0, 10)
 	fm.childFunc = &tmp
@@ -40,22 +83,82 @@ func InitFuncMaker(pkg *types.Package) *FuncMaker {
 	return fm
 }
 
+// Err 生成中に発生したエラーを返す。
+func (fm *FuncMaker) Err() error {
+	if len(*fm.errs) == 0 {
+		return fm.privateError()
+	}
+	return (*fm.errs)[0]
+}
+
+func (fm *FuncMaker) addError(err error) {
+	*fm.errs = append(*fm.errs, err)
+}
+
+// newVar 関数内で一意な一時変数名を返す。
+func (fm *FuncMaker) newVar() string {
+	*fm.tmpVars++
+	return fmt.Sprintf("v%d", *fm.tmpVars)
+}
+
 // MakeFunc make function
 // TODO fix only named type
 func (fm *FuncMaker) MakeFunc(dstType, srcType Type) {
 	dstName, _ := fm.formatPkgType(dstType.typ)
 	srcName, _ := fm.formatPkgType(srcType.typ)
 
-	var err error
-	fm.funcName, err = fm.getFuncName(dstType.typ, srcType.typ)
+	conv, err := fm.getConverter(dstType.typ, srcType.typ)
 	if err != nil {
 		return
 	}
+	fm.funcName = conv.key()
 
-	fmt.Fprintf(fm.buf, "func %s(src %s) (dst %s) {\n",
-		fm.funcName, srcName, dstName)
-	fm.makeFunc(Type{typ: dstType.typ}, Type{typ: srcType.typ}, "dst", "src", "", nil)
+	// 生成を始めた関数は、visited map を作る
+	root := conv.graph && (fm.parentFunc == nil || fm.collection)
+	if root {
+		conv.graph = false
+	}
+	headerStart := fm.buf.Len()
+	header, dstSelector, srcSelector := conv.header(dstName, srcName)
+	fmt.Fprint(fm.buf, header)
+	bodyStart := fm.buf.Len()
+	fm.rootDst = dstSelector
+	body := func() {
+		fm.constructor(dstType, srcType, dstSelector, srcSelector)
+		written := fm.makeFunc(Type{typ: dstType.typ}, Type{typ: srcType.typ}, dstSelector, srcSelector, "", nil)
+		if !written {
+			// 変換元が無くても、定数・初期値は代入する
+			if dstT, ok := dstType.typ.Underlying().(*types.Struct); ok {
+				fm.writeValues(TypeStruct{typ: dstT, name: dstType.typ.String()}, dstSelector)
+			}
+		}
+	}
+	body()
+	if *fm.fallible && !fm.names.fallible[conv.key()] {
+		fm.names.fallible[conv.key()] = true
+		// 自身を呼び出していれば、error を返す呼び出しにして作り直す
+		if *fm.recursive {
+			fm.buf.Truncate(bodyStart)
+			fm.dstWrittenSelector = map[string]struct{}{}
+			*fm.tmpVars = 0
+			body()
+		}
+	}
+	if root && *fm.visited {
+		body := append([]byte(nil), fm.buf.Bytes()[bodyStart:]...)
+		fm.buf.Truncate(bodyStart)
+		fmt.Fprintf(fm.buf, "visited := %s{}\n", visitedType)
+		fm.buf.Write(body)
+	}
 	fmt.Fprintf(fm.buf, "return\n}\n\n")
+	if *fm.fallible {
+		conv.err = true
+		fm.replaceHeader(headerStart, bodyStart, conv, dstName, srcName)
+	}
+
+	if isNamedPair(dstType.typ, srcType.typ) {
+		fm.makeCollections(dstType.typ, srcType.typ)
+	}
 }
 
 // WriteBytes 全ての関数を書き出す。
@@ -78,13 +181,34 @@ func (fm *FuncMaker) deferWrite(f func(*FuncMaker) bool) bool {
 		childFunc:  fm.childFunc,
 
 		dstWrittenSelector: fm.dstWrittenSelector,
+		errs:               fm.errs,
+		tmpVars:            fm.tmpVars,
+		helpers:            fm.helpers,
+		privates:           fm.privates,
+		names:              fm.names,
+		imports:            fm.imports,
+		noGuard:            fm.noGuard,
+		slice:              fm.slice,
+		time:               fm.time,
+		rootDst:            fm.rootDst,
+		fallible:           fm.fallible,
+		recursive:          fm.recursive,
+		visited:            fm.visited,
+		lazy:               fm.lazy,
 	}
 
+	var guards, depth int
+	if fm.lazy != nil {
+		guards, depth = len(fm.lazy.guards), fm.lazy.depth
+	}
 	written := f(tmpFm)
 	if written {
 		fm.buf.Write(tmpFm.buf.Bytes())
 		// fm.childFunc = tmpFm.childFunc
 		fm.dstWrittenSelector = tmpFm.dstWrittenSelector
+	} else if fm.lazy != nil {
+		// 書き込まなかった if 文は記録しない
+		fm.lazy.guards, fm.lazy.depth = fm.lazy.guards[:guards], depth
 	}
 	return written
 }
@@ -101,22 +225,54 @@ func (fm *FuncMaker) makeFunc(dst, src Type, dstSelector, srcSelector, index str
 		return false
 	}
 
-	if checkHistory(dst.typ, src.typ, history) {
+	// named type 同士は関数を呼び出すので、再帰する型でも無限ループにならない
+	if !isNamedPair(dst.typ, src.typ) && checkHistory(dst.typ, src.typ, history) {
 		return false
 	}
 	history = append(history, [2]types.Type{dst.typ, src.typ})
 
-	if types.IdenticalIgnoreTags(dst.typ, src.typ) {
+	if types.IdenticalIgnoreTags(dst.typ, src.typ) && !fm.deepCopy(src.typ) {
+		assign := fmt.Sprintf("%s = %s\n", dstSelector, srcSelector)
+		_, dstBasic := dst.typ.(*types.Basic)
 		if dst.name != "" && dst.name != src.name {
-			fmt.Fprintf(fm.buf, "%s = %s(%s)\n", dstSelector, fm.formatPkgString(dst.name), srcSelector)
-		} else {
-			fmt.Fprintf(fm.buf, "%s = %s\n", dstSelector, srcSelector)
+			assign = fmt.Sprintf("%s = %s(%s)\n", dstSelector, fm.formatPkgString(dst.name), srcSelector)
+		} else if dst.name == "" && src.name != "" && dstBasic {
+			// named type から基本型へは、代入できないので変換する
+			assign = fmt.Sprintf("%s = %s(%s)\n", dstSelector, dst.typ, srcSelector)
+		}
+
+		if isCollection(dst.typ) && NilCollection != NilDefault {
+			// 同じ型のスライス・マップにも、-nil の扱いを適用する
+			return fm.identicalCollection(dst.typ, src.typ, assign, dstSelector, srcSelector, index)
+		}
+
+		guard, ok := fm.applyGuard(src.typ, srcSelector, index)
+		id := 0
+		if ok {
+			id = fm.openGuard(guard)
+		}
+		fmt.Fprint(fm.buf, assign)
+		if ok {
+			fm.closeGuard(id)
 		}
 
 		fm.dstWrittenSelector[dstSelector] = struct{}{}
 		return true
 	}
 
+	// sql.NullString などと、値・ポインタ
+	if written, ok := fm.nullAndOther(dst, src, dstSelector, srcSelector, index, history); ok {
+		return written
+	}
+	// time.Time time.Duration と、整数・文字列など
+	if written, ok := fm.timeAndOther(dst, src, dstSelector, srcSelector, index); ok {
+		return written
+	}
+	// String, MarshalText, UnmarshalText
+	if written, ok := fm.textAndOther(dst, src, dstSelector, srcSelector, index); ok {
+		return written
+	}
+
 	switch dstT := dst.typ.(type) {
 	case *types.Basic:
 		switch srcT := src.typ.(type) {
@@ -126,7 +282,7 @@ func (fm *FuncMaker) makeFunc(dst, src Type, dstSelector, srcSelector, index str
 		case *types.Slice:
 			return fm.otherAndSlice(dst, TypeSlice{typ: srcT, name: src.name}, dstSelector, srcSelector, index, history)
 		case *types.Struct:
-			return fm.otherAndStruct(dst, TypeStruct{typ: srcT, name: src.name}, dstSelector, srcSelector, index, history)
+			return fm.otherAndStruct(dst, TypeStruct{typ: srcT, name: src.name, orig: src.orig}, dstSelector, srcSelector, index, history)
 		case *types.Pointer:
 			return fm.otherAndPointer(dst, TypePointer{typ: srcT, name: src.name}, dstSelector, srcSelector, index, history)
 		default:
@@ -158,7 +314,7 @@ func (fm *FuncMaker) makeFunc(dst, src Type, dstSelector, srcSelector, index str
 			return fm.sliceAndSlice(TypeSlice{typ: dstT, name: dst.name}, TypeSlice{typ: srcT, name: src.name}, dstSelector, srcSelector, index, history)
 		case *types.Struct:
 			return fm.sliceAndOther(TypeSlice{typ: dstT, name: dst.name}, src, dstSelector, srcSelector, index, history) ||
-				fm.otherAndStruct(dst, TypeStruct{typ: srcT, name: src.name}, dstSelector, srcSelector, index, history)
+				fm.otherAndStruct(dst, TypeStruct{typ: srcT, name: src.name, orig: src.orig}, dstSelector, srcSelector, index, history)
 		case *types.Pointer:
 			return fm.otherAndPointer(dst, TypePointer{typ: srcT, name: src.name}, dstSelector, srcSelector, index, history)
 		default:
@@ -168,25 +324,54 @@ func (fm *FuncMaker) makeFunc(dst, src Type, dstSelector, srcSelector, index str
 	case *types.Struct:
 		switch srcT := src.typ.(type) {
 		case *types.Basic:
-			return fm.structAndOther(TypeStruct{typ: dstT, name: dst.name}, src, dstSelector, srcSelector, index, history)
+			return fm.structAndOther(TypeStruct{typ: dstT, name: dst.name, orig: dst.orig}, src, dstSelector, srcSelector, index, history)
 		case *types.Named:
 			return fm.otherAndNamed(dst, TypeNamed{typ: srcT, name: src.name}, dstSelector, srcSelector, index, history)
 		case *types.Slice:
 			return fm.otherAndSlice(dst, TypeSlice{typ: srcT, name: src.name}, dstSelector, srcSelector, index, history) ||
-				fm.structAndOther(TypeStruct{typ: dstT, name: dst.name}, src, dstSelector, srcSelector, index, history)
+				fm.structAndOther(TypeStruct{typ: dstT, name: dst.name, orig: dst.orig}, src, dstSelector, srcSelector, index, history)
+		case *types.Struct:
+			return fm.structAndStruct(TypeStruct{typ: dstT, name: dst.name, orig: dst.orig}, TypeStruct{typ: srcT, name: src.name, orig: src.orig}, dstSelector, srcSelector, index, history) ||
+				fm.structAndOther(TypeStruct{typ: dstT, name: dst.name, orig: dst.orig}, src, dstSelector, srcSelector, index, history) ||
+				fm.otherAndStruct(dst, TypeStruct{typ: srcT, name: src.name, orig: src.orig}, dstSelector, srcSelector, index, history)
+		case *types.Pointer:
+			return fm.otherAndPointer(dst, TypePointer{typ: srcT, name: src.name}, dstSelector, srcSelector, index, history)
+		default:
+			return fm.structAndOther(TypeStruct{typ: dstT, name: dst.name, orig: dst.orig}, src, dstSelector, srcSelector, index, history)
+		}
+
+	case *types.Map:
+		switch srcT := src.typ.(type) {
+		case *types.Named:
+			return fm.otherAndNamed(dst, TypeNamed{typ: srcT, name: src.name}, dstSelector, srcSelector, index, history)
+		case *types.Map:
+			return fm.mapAndMap(TypeMap{typ: dstT, name: dst.name}, TypeMap{typ: srcT, name: src.name}, dstSelector, srcSelector, index, history)
+		case *types.Pointer:
+			return fm.otherAndPointer(dst, TypePointer{typ: srcT, name: src.name}, dstSelector, srcSelector, index, history)
+		case *types.Struct:
+			return fm.otherAndStruct(dst, TypeStruct{typ: srcT, name: src.name, orig: src.orig}, dstSelector, srcSelector, index, history)
+		default:
+		}
+
+	case *types.Array:
+		switch srcT := src.typ.(type) {
+		case *types.Named:
+			return fm.otherAndNamed(dst, TypeNamed{typ: srcT, name: src.name}, dstSelector, srcSelector, index, history)
+		case *types.Array:
+			return fm.arrayAndArray(TypeArray{typ: dstT, name: dst.name}, TypeArray{typ: srcT, name: src.name}, dstSelector, srcSelector, index, history)
+		case *types.Slice:
+			return fm.otherAndSlice(dst, TypeSlice{typ: srcT, name: src.name}, dstSelector, srcSelector, index, history)
 		case *types.Struct:
-			return fm.structAndStruct(TypeStruct{typ: dstT, name: dst.name}, TypeStruct{typ: srcT, name: src.name}, dstSelector, srcSelector, index, history) ||
-				fm.structAndOther(TypeStruct{typ: dstT, name: dst.name}, src, dstSelector, srcSelector, index, history) ||
-				fm.otherAndStruct(dst, TypeStruct{typ: srcT, name: src.name}, dstSelector, srcSelector, index, history)
+			return fm.otherAndStruct(dst, TypeStruct{typ: srcT, name: src.name, orig: src.orig}, dstSelector, srcSelector, index, history)
 		case *types.Pointer:
 			return fm.otherAndPointer(dst, TypePointer{typ: srcT, name: src.name}, dstSelector, srcSelector, index, history)
 		default:
-			return fm.structAndOther(TypeStruct{typ: dstT, name: dst.name}, src, dstSelector, srcSelector, index, history)
 		}
 
 	case *types.Pointer:
 		switch srcT := src.typ.(type) {
 		case *types.Basic:
+			return fm.pointerAndOther(TypePointer{typ: dstT, name: dst.name}, src, dstSelector, srcSelector, index, history)
 		case *types.Named:
 			return fm.pointerAndOther(TypePointer{typ: dstT, name: dst.name}, src, dstSelector, srcSelector, index, history)
 		case *types.Slice:
@@ -194,6 +379,9 @@ func (fm *FuncMaker) makeFunc(dst, src Type, dstSelector, srcSelector, index str
 		case *types.Struct:
 			return fm.pointerAndOther(TypePointer{typ: dstT, name: dst.name}, src, dstSelector, srcSelector, index, history)
 		case *types.Pointer:
+			if graphPair(dstT, srcT) {
+				return fm.pointerGraph(TypePointer{typ: dstT, name: dst.name}, TypePointer{typ: srcT, name: src.name}, dstSelector, srcSelector)
+			}
 			return fm.pointerAndPointer(TypePointer{typ: dstT, name: dst.name}, TypePointer{typ: srcT, name: src.name}, dstSelector, srcSelector, index, history)
 		default:
 			return fm.pointerAndOther(TypePointer{typ: dstT, name: dst.name}, src, dstSelector, srcSelector, index, history)
@@ -207,7 +395,7 @@ func (fm *FuncMaker) makeFunc(dst, src Type, dstSelector, srcSelector, index str
 		case *types.Slice:
 			return fm.otherAndSlice(dst, TypeSlice{typ: srcT, name: src.name}, dstSelector, srcSelector, index, history)
 		case *types.Struct:
-			return fm.otherAndStruct(dst, TypeStruct{typ: srcT, name: src.name}, dstSelector, srcSelector, index, history)
+			return fm.otherAndStruct(dst, TypeStruct{typ: srcT, name: src.name, orig: src.orig}, dstSelector, srcSelector, index, history)
 		case *types.Pointer:
 			return fm.otherAndPointer(dst, TypePointer{typ: srcT, name: src.name}, dstSelector, srcSelector, index, history)
 		default:
@@ -0,0 +1,75 @@
+package analysis
+
+import (
+	"fmt"
+	"go/types"
+	"strings"
+)
+
+// GetterMode getter メソッドを src のフィールドとして扱うか
+type GetterMode int
+
+const (
+	// GetterNone getter を使わない
+	GetterNone GetterMode = iota
+	// GetterFallback フィールドを優先し、次に getter を使う
+	GetterFallback
+	// GetterPrefer getter をフィールドより優先する (protobuf の GetX など)
+	GetterPrefer
+)
+
+// Getter src の getter メソッドの扱い
+var Getter = GetterNone
+
+// ParseGetterMode parses the value of the -getter flag.
+func ParseGetterMode(s string) (GetterMode, error) {
+	switch s {
+	case "", "none":
+		return GetterNone, nil
+	case "fallback":
+		return GetterFallback, nil
+	case "prefer":
+		return GetterPrefer, nil
+	}
+	return GetterNone, fmt.Errorf("unknown getter mode %q", s)
+}
+
+// getters 引数が無く、戻り値が一つのメソッドを仮想的なフィールドとして返す。
+// GetName は Name として扱う。
+func (fm *FuncMaker) getters(t types.Type, srcSelector string) []srcField {
+	if Getter == GetterNone || t == nil {
+		return nil
+	}
+	if _, ok := t.(*types.Named); !ok {
+		return nil
+	}
+
+	// src は addressable なので、pointer receiver のメソッドも呼べる
+	// ただし、メソッドの戻り値は addressable ではない
+	mset := types.NewMethodSet(types.NewPointer(t))
+	if strings.HasSuffix(srcSelector, ")") && !strings.HasPrefix(srcSelector, "(*") {
+		mset = types.NewMethodSet(t)
+	}
+	fields := make([]srcField, 0, mset.Len())
+	for i := 0; i < mset.Len(); i++ {
+		f, ok := mset.At(i).Obj().(*types.Func)
+		if !ok || !f.Exported() {
+			continue
+		}
+		sig := f.Type().(*types.Signature)
+		if sig.Params().Len() != 0 || sig.Results().Len() != 1 {
+			continue
+		}
+
+		name := f.Name()
+		if len(name) > 3 && strings.HasPrefix(name, "Get") {
+			name = name[3:]
+		}
+		fields = append(fields, srcField{
+			name:     name,
+			typ:      sig.Results().At(0).Type(),
+			selector: fmt.Sprintf("%s.%s()", srcSelector, f.Name()),
+		})
+	}
+	return fields
+}
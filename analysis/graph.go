@@ -0,0 +1,61 @@
+package analysis
+
+import (
+	"fmt"
+	"go/types"
+)
+
+// Graph ポインタを visited map を使って変換し、循環と共有を保つ
+var Graph = false
+
+// visitedType 変換したポインタ。key は [2]interface{}{src, (*Dst)(nil)}
+const visitedType = "map[interface{}]interface{}"
+
+// graphPair visited map を使って変換するポインタの組か
+func graphPair(dstT, srcT *types.Pointer) bool {
+	if !Graph {
+		return false
+	}
+	_, dok := dstT.Elem().(*types.Named)
+	_, sok := srcT.Elem().(*types.Named)
+	return dok && sok
+}
+
+// pointerGraph 一度変換したポインタは、同じポインタを返す関数を呼び出す。
+func (fm *FuncMaker) pointerGraph(dstT, srcT TypePointer, dstSelector, srcSelector string) bool {
+	conv, err := fm.getConverter(dstT.typ, srcT.typ)
+	if err != nil {
+		return false
+	}
+	if !fm.isAlreadyExist(conv.key()) {
+		fm.newChild().makeGraphFunc(conv, dstT, srcT)
+	}
+
+	fmt.Fprintf(fm.buf, "%s\n", fm.call(conv, dstSelector, srcSelector))
+	fm.dstWrittenSelector[dstSelector] = struct{}{}
+	return true
+}
+
+// makeGraphFunc ポインタを変換する関数を作る。
+// 指している先を変換する前に visited に登録するので、循環していても終了する。
+func (fm *FuncMaker) makeGraphFunc(conv converter, dstT, srcT TypePointer) {
+	dstName, _ := fm.formatPkgType(dstT.typ)
+	srcName, _ := fm.formatPkgType(srcT.typ)
+	elemName, err := fm.formatPkgType(dstT.typ.Elem())
+	if err != nil {
+		return
+	}
+	fm.funcName = conv.key()
+
+	header, dstSelector, srcSelector := conv.header(dstName, srcName)
+	fmt.Fprint(fm.buf, header)
+	fmt.Fprintf(fm.buf, "if src == nil {\nreturn nil\n}\n")
+	fmt.Fprintf(fm.buf, "key := [2]interface{}{src, dst}\n")
+	fmt.Fprintf(fm.buf, "if v, ok := visited[key]; ok {\nreturn v.(%s)\n}\n", dstName)
+	fmt.Fprintf(fm.buf, "dst = new(%s)\nvisited[key] = dst\n", elemName)
+	fm.makeFunc(Type{typ: dstT.typ.Elem()}, Type{typ: srcT.typ.Elem()}, fmt.Sprintf("(*%s)", dstSelector), fmt.Sprintf("(*%s)", srcSelector), "", nil)
+	if *fm.fallible {
+		fm.addError(errGraph)
+	}
+	fmt.Fprintf(fm.buf, "return\n}\n\n")
+}
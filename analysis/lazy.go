@@ -0,0 +1,148 @@
+package analysis
+
+import (
+	"fmt"
+	"go/types"
+	"strings"
+)
+
+// NilIfZero src の struct がゼロ値であれば、ポインタの dst を nil のままにする
+var NilIfZero = false
+
+// zeroGuard src がゼロ値で無いときだけ確保するための条件。struct のみ
+func (fm *FuncMaker) zeroGuard(t types.Type, srcSelector string) (string, bool) {
+	if !NilIfZero {
+		return "", false
+	}
+	if _, ok := t.Underlying().(*types.Struct); !ok {
+		return "", false
+	}
+	if zero, err := fm.zeroValue(t); err == nil {
+		return fmt.Sprintf("%s != %s", srcSelector, zero), true
+	}
+	// 比較できない struct
+	return fmt.Sprintf("!reflect.ValueOf(%s).IsZero()", srcSelector), true
+}
+
+// pointerAlloc 確保するポインタ
+type pointerAlloc struct {
+	selector string
+	typeName string
+}
+
+// allocPointers ポインタを順に確保する文。check であれば、nil のときだけ確保する
+func allocPointers(allocs []pointerAlloc, check bool) string {
+	var b strings.Builder
+	for _, a := range allocs {
+		if check && !inPlace() {
+			fmt.Fprintf(&b, "if %s == nil {\n%s = new(%s)\n}\n", a.selector, a.selector, a.typeName)
+			continue
+		}
+		b.WriteString(allocPointer(a.selector, a.typeName))
+	}
+	return b.String()
+}
+
+// lazyGuard ポインタを確保する文の中で書き込んだ、トップレベルの if 文
+type lazyGuard struct {
+	start int
+	// head if cond {\n の長さ
+	head int
+	// size 閉じた if 文の長さ。閉じていなければ 0
+	size int
+	// lazy 条件を満たしたときだけ確保できるか。
+	// else がある if 文、条件でポインタの先を読む if 文は、書き込む前に確保できない
+	lazy bool
+}
+
+// lazyGuards ポインタを確保する文の中で書き込んだ if 文。生成しながら記録する
+type lazyGuards struct {
+	allocs []pointerAlloc
+	// depth 閉じていない if 文の数
+	depth  int
+	guards []lazyGuard
+}
+
+// openGuard if cond { を書き込む。closeGuard に渡す番号を返す
+func (fm *FuncMaker) openGuard(cond string) int {
+	start := fm.buf.Len()
+	fmt.Fprintf(fm.buf, "if %s {\n", cond)
+	if fm.lazy == nil {
+		return 0
+	}
+	fm.lazy.depth++
+	if fm.lazy.depth > 1 {
+		return 0
+	}
+	fm.lazy.guards = append(fm.lazy.guards, lazyGuard{
+		start: start,
+		head:  fm.buf.Len() - start,
+		lazy:  !fm.lazy.reads(cond),
+	})
+	return len(fm.lazy.guards)
+}
+
+// closeGuard openGuard の if 文を閉じる
+func (fm *FuncMaker) closeGuard(id int) {
+	fmt.Fprintf(fm.buf, "}\n")
+	fm.endGuard(id, true)
+}
+
+// closeGuardElse openGuard の if 文を閉じ、else で stmt を書き込む
+func (fm *FuncMaker) closeGuardElse(id int, stmt string) {
+	fmt.Fprintf(fm.buf, "} else {\n%s}\n", stmt)
+	fm.endGuard(id, false)
+}
+
+func (fm *FuncMaker) endGuard(id int, lazy bool) {
+	if fm.lazy == nil {
+		return
+	}
+	fm.lazy.depth--
+	if id == 0 {
+		return
+	}
+	g := &fm.lazy.guards[id-1]
+	g.size = fm.buf.Len() - g.start
+	g.lazy = g.lazy && lazy
+}
+
+// reads 条件で確保するポインタの先を読むか
+func (l *lazyGuards) reads(cond string) bool {
+	for _, a := range l.allocs {
+		if strings.Contains(cond, a.selector) {
+			return true
+		}
+	}
+	return false
+}
+
+// guarded 書き込んだ size の文が、全て条件を満たしたときだけ確保できる if 文か
+func (l *lazyGuards) guarded(size int) bool {
+	for _, g := range l.guards {
+		if !g.lazy || g.size == 0 {
+			return false
+		}
+		size -= g.size
+	}
+	return size == 0
+}
+
+// lazyAlloc 指している先に書き込む文 body の前に、ポインタを確保する。
+// body が全て条件を満たしたときだけ確保できる if 文であれば、if 文の中で確保する。
+func lazyAlloc(body string, lazy *lazyGuards) string {
+	if !lazy.guarded(len(body)) {
+		return allocPointers(lazy.allocs, false) + body
+	}
+
+	alloc := allocPointers(lazy.allocs, len(lazy.guards) > 1)
+	var b strings.Builder
+	pos := 0
+	for _, g := range lazy.guards {
+		b.WriteString(body[pos : pos+g.head])
+		b.WriteString(alloc)
+		b.WriteString(body[pos+g.head : pos+g.size])
+		pos += g.size
+	}
+	return b.String()
+}
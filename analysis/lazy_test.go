@@ -0,0 +1,122 @@
+package analysis
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func Test_lazyAlloc(t *testing.T) {
+	allocs := []pointerAlloc{{selector: "dst.X", typeName: "T"}}
+	tests := []struct {
+		name  string
+		write func(fm *FuncMaker)
+		want  string
+	}{
+		{
+			name: "assign",
+			write: func(fm *FuncMaker) {
+				fmt.Fprintf(fm.buf, "(*dst.X).A = src.A\n")
+			},
+			want: "dst.X = new(T)\n(*dst.X).A = src.A\n",
+		},
+		{
+			name: "one if",
+			write: func(fm *FuncMaker) {
+				id := fm.openGuard("src.A != nil")
+				fmt.Fprintf(fm.buf, "(*dst.X).A = (*src.A)\n")
+				fm.closeGuard(id)
+			},
+			want: "if src.A != nil {\ndst.X = new(T)\n(*dst.X).A = (*src.A)\n}\n",
+		},
+		{
+			name: "two ifs",
+			write: func(fm *FuncMaker) {
+				for _, f := range []string{"A", "B"} {
+					id := fm.openGuard(fmt.Sprintf("src.%s != nil", f))
+					fmt.Fprintf(fm.buf, "(*dst.X).%s = (*src.%s)\n", f, f)
+					fm.closeGuard(id)
+				}
+			},
+			want: "if src.A != nil {\nif dst.X == nil {\ndst.X = new(T)\n}\n(*dst.X).A = (*src.A)\n}\n" +
+				"if src.B != nil {\nif dst.X == nil {\ndst.X = new(T)\n}\n(*dst.X).B = (*src.B)\n}\n",
+		},
+		{
+			name: "brace in string",
+			write: func(fm *FuncMaker) {
+				id := fm.openGuard("src.A != nil")
+				fmt.Fprintf(fm.buf, "(*dst.X).A = strings.Join(src.A, \"{\")\n")
+				fm.closeGuard(id)
+			},
+			want: "if src.A != nil {\ndst.X = new(T)\n(*dst.X).A = strings.Join(src.A, \"{\")\n}\n",
+		},
+		{
+			name: "else",
+			write: func(fm *FuncMaker) {
+				id := fm.openGuard("src.A != nil")
+				fmt.Fprintf(fm.buf, "(*dst.X).A = src.A\n")
+				fm.closeGuardElse(id, "(*dst.X).A = nil\n")
+			},
+			want: "dst.X = new(T)\nif src.A != nil {\n(*dst.X).A = src.A\n} else {\n(*dst.X).A = nil\n}\n",
+		},
+		{
+			name: "error",
+			write: func(fm *FuncMaker) {
+				fmt.Fprintf(fm.buf, "if (*dst.X), err = f(src); err != nil {\nreturn\n}\n")
+			},
+			want: "dst.X = new(T)\nif (*dst.X), err = f(src); err != nil {\nreturn\n}\n",
+		},
+		{
+			name: "ifzero",
+			write: func(fm *FuncMaker) {
+				id := fm.openGuard("src.A != nil")
+				fmt.Fprintf(fm.buf, "(*dst.X).A = (*src.A)\n")
+				fm.closeGuard(id)
+				fmt.Fprintf(fm.buf, "if (*dst.X).A == \"\" {\n(*dst.X).A = \"x\"\n}\n")
+			},
+			want: "dst.X = new(T)\nif src.A != nil {\n(*dst.X).A = (*src.A)\n}\nif (*dst.X).A == \"\" {\n(*dst.X).A = \"x\"\n}\n",
+		},
+		{
+			name: "unwritten if",
+			write: func(fm *FuncMaker) {
+				fm.deferWrite(func(tmpFm *FuncMaker) bool {
+					tmpFm.openGuard("src.B != nil")
+					return false
+				})
+				id := fm.openGuard("src.A != nil")
+				fmt.Fprintf(fm.buf, "(*dst.X).A = (*src.A)\n")
+				fm.closeGuard(id)
+			},
+			want: "if src.A != nil {\ndst.X = new(T)\n(*dst.X).A = (*src.A)\n}\n",
+		},
+		{
+			name: "nested if",
+			write: func(fm *FuncMaker) {
+				id := fm.openGuard("src.A != nil")
+				inner := fm.openGuard("src.A.B != nil")
+				fmt.Fprintf(fm.buf, "(*dst.X).B = (*src.A.B)\n")
+				fm.closeGuard(inner)
+				fm.closeGuard(id)
+			},
+			want: "if src.A != nil {\ndst.X = new(T)\nif src.A.B != nil {\n(*dst.X).B = (*src.A.B)\n}\n}\n",
+		},
+		{
+			name: "condition reads the pointer",
+			write: func(fm *FuncMaker) {
+				id := fm.openGuard("(*dst.X).A == \"\"")
+				fmt.Fprintf(fm.buf, "(*dst.X).A = \"x\"\n")
+				fm.closeGuard(id)
+			},
+			want: "dst.X = new(T)\nif (*dst.X).A == \"\" {\n(*dst.X).A = \"x\"\n}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fm := &FuncMaker{buf: new(bytes.Buffer), lazy: &lazyGuards{allocs: allocs}}
+			tt.write(fm)
+			if got := lazyAlloc(fm.buf.String(), fm.lazy); got != tt.want {
+				t.Errorf("lazyAlloc() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package analysis
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MatchMode field名の比較方法
+type MatchMode int
+
+const (
+	// MatchExact 完全一致
+	MatchExact MatchMode = iota
+	// MatchIgnoreCase 大文字小文字を区別しない (UserID == UserId)
+	MatchIgnoreCase
+	// MatchNormalize `_` `-` を取り除き、大文字小文字を区別しない (user_id == UserID)
+	MatchNormalize
+)
+
+var (
+	// Match structAndStruct で使われる比較方法
+	Match = MatchExact
+	// StripPrefixes 比較する前に取り除く prefix (EventName -> Name)
+	StripPrefixes []string
+	// StripSuffixes 比較する前に取り除く suffix (NameStr -> Name)
+	StripSuffixes []string
+)
+
+// ParseMatchMode parses the value of the -match flag.
+func ParseMatchMode(s string) (MatchMode, error) {
+	switch s {
+	case "", "exact":
+		return MatchExact, nil
+	case "ignorecase":
+		return MatchIgnoreCase, nil
+	case "normalize":
+		return MatchNormalize, nil
+	}
+	return MatchExact, fmt.Errorf("unknown match mode %q", s)
+}
+
+// fuzzyMatch 完全一致以外の比較を行うか
+func fuzzyMatch() bool {
+	return Match != MatchExact || len(StripPrefixes) != 0 || len(StripSuffixes) != 0
+}
+
+// matchName dst と src の field名が対応するかを返す。
+// exact が true のときは完全一致のみを見る。
+func matchName(dName, sName string, exact bool) bool {
+	if exact {
+		return dName == sName
+	}
+
+	for _, d := range stripName(dName) {
+		for _, s := range stripName(sName) {
+			if equalName(d, s) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func equalName(a, b string) bool {
+	switch Match {
+	case MatchIgnoreCase:
+		return strings.EqualFold(a, b)
+	case MatchNormalize:
+		return normalizeName(a) == normalizeName(b)
+	default:
+		return a == b
+	}
+}
+
+func normalizeName(name string) string {
+	name = strings.NewReplacer("_", "", "-", "").Replace(name)
+	return strings.ToLower(name)
+}
+
+// stripName name と、prefix suffix を取り除いた候補を返す。
+func stripName(name string) []string {
+	names := []string{name}
+	for _, p := range StripPrefixes {
+		if p != "" && len(name) > len(p) && strings.HasPrefix(name, p) {
+			names = append(names, name[len(p):])
+		}
+	}
+	for _, s := range StripSuffixes {
+		for _, n := range names {
+			if s != "" && len(n) > len(s) && strings.HasSuffix(n, s) {
+				names = append(names, n[:len(n)-len(s)])
+			}
+		}
+	}
+	return names
+}
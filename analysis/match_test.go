@@ -0,0 +1,81 @@
+package analysis
+
+import "testing"
+
+func Test_matchName(t *testing.T) {
+	type args struct {
+		dName string
+		sName string
+		exact bool
+	}
+	tests := []struct {
+		name     string
+		match    MatchMode
+		prefixes []string
+		suffixes []string
+		args     args
+		want     bool
+	}{
+		{
+			name:  "exact",
+			match: MatchExact,
+			args:  args{dName: "UserID", sName: "UserId"},
+			want:  false,
+		},
+		{
+			name:  "ignorecase",
+			match: MatchIgnoreCase,
+			args:  args{dName: "UserID", sName: "UserId"},
+			want:  true,
+		},
+		{
+			name:  "ignorecase, exact pass",
+			match: MatchIgnoreCase,
+			args:  args{dName: "UserID", sName: "UserId", exact: true},
+			want:  false,
+		},
+		{
+			name:  "normalize",
+			match: MatchNormalize,
+			args:  args{dName: "UserID", sName: "user_id"},
+			want:  true,
+		},
+		{
+			name:     "strip prefix",
+			match:    MatchExact,
+			prefixes: []string{"Event"},
+			args:     args{dName: "Name", sName: "EventName"},
+			want:     true,
+		},
+		{
+			name:     "strip prefix and suffix",
+			match:    MatchNormalize,
+			prefixes: []string{"Event"},
+			suffixes: []string{"Str"},
+			args:     args{dName: "name", sName: "EventNameStr"},
+			want:     true,
+		},
+		{
+			name:     "do not strip whole name",
+			match:    MatchExact,
+			prefixes: []string{"Event"},
+			args:     args{dName: "", sName: "Event"},
+			want:     false,
+		},
+	}
+	defer func() {
+		Match = MatchExact
+		StripPrefixes = nil
+		StripSuffixes = nil
+	}()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Match = tt.match
+			StripPrefixes = tt.prefixes
+			StripSuffixes = tt.suffixes
+			if got := matchName(tt.args.dName, tt.args.sName, tt.args.exact); got != tt.want {
+				t.Errorf("matchName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package analysis
+
+import (
+	"fmt"
+	"go/types"
+	"strings"
+)
+
+// MakeMultiFunc 複数の src から dst を作る関数、一つの src から複数の dst を作る関数を生成する。
+// func ConvEventRoomToEventResponse(src0 Event, src1 Room) (dst EventResponse)
+// func ConvdbEventTodomainEventdomainRoom(src db.Event) (dst0 domain.Event, dst1 domain.Room)
+//
+// 全ての src のフィールドから dst のフィールドを探す。同じ名前のフィールドがある場合は、
+// 名前が完全に一致するもの、先に指定した src のものを優先する。
+// dst のタグ `cvt:"Room:Name"` で src の型を、src のタグ `cvt:"Room:ID"` で dst の型を指定できる。
+func (fm *FuncMaker) MakeMultiFunc(dstTypes, srcTypes []Type) {
+	dstStructs := make([]TypeStruct, len(dstTypes))
+	for i, dst := range dstTypes {
+		dstT, ok := dst.typ.Underlying().(*types.Struct)
+		if !ok {
+			fm.addError(fmt.Errorf("cannot convert into %s: the destination of multiple types must be a struct", dst.name))
+			return
+		}
+		dstStructs[i] = TypeStruct{typ: dstT, name: dst.typ.String(), orig: dst.typ}
+	}
+	conv, err := fm.multiConverter(dstTypes, srcTypes)
+	if err != nil {
+		return
+	}
+	fm.funcName = conv.key()
+
+	// src のフィールドは、全ての dst で共有する
+	params := make([]string, len(srcTypes))
+	sources := make([]structSource, 0, len(srcTypes))
+	sFields := make([]srcField, 0)
+	for i, src := range srcTypes {
+		srcName, _ := fm.formatPkgType(src.typ)
+		selector := multiSelector("src", i, len(srcTypes))
+		params[i] = selector + " " + srcName
+
+		if srcT, ok := src.typ.Underlying().(*types.Struct); ok {
+			ts := TypeStruct{typ: srcT, name: src.name, orig: src.typ}
+			sources = append(sources, structSource{typ: ts, selector: selector})
+			sFields = append(sFields, fm.srcFields(ts, selector)...)
+			continue
+		}
+		// struct で無い src は、一つのフィールドとして扱う
+		sFields = append(sFields, srcField{
+			name:     sourceFieldName(src.typ),
+			typ:      src.typ,
+			selector: selector,
+		})
+	}
+
+	results := make([]string, len(dstTypes))
+	doc := make([]string, 0, len(dstTypes))
+	body := new(strings.Builder)
+	for i, dst := range dstTypes {
+		dstName, _ := fm.formatPkgType(dst.typ)
+		selector := multiSelector("dst", i, len(dstTypes))
+		results[i] = selector + " " + dstName
+
+		feeds := make([]string, 0)
+		fm.rootDst = selector
+		fm.feeds = &feeds
+		start := fm.buf.Len()
+		fm.constructorFields(dst, sFields, selector)
+		if !fm.structAndSources(dstStructs[i], sources, sFields, selector, "", nil) {
+			fm.writeValues(dstStructs[i], selector)
+		}
+		body.Write(fm.buf.Bytes()[start:])
+		fm.buf.Truncate(start)
+		fm.feeds = nil
+		if len(feeds) == 0 {
+			feeds = append(feeds, "none")
+		}
+		doc = append(doc, fmt.Sprintf("// %s: %s\n", results[i], strings.Join(feeds, ", ")))
+	}
+
+	// 複数の dst を返す場合は、それぞれに書き込んだ src のフィールドを書く
+	if len(dstTypes) > 1 {
+		fmt.Fprintf(fm.buf, "// %s converts src into %d destinations.\n//\n%s", conv.name, len(dstTypes), strings.Join(doc, ""))
+	}
+	if *fm.fallible {
+		results = append(results, "err error")
+	}
+	fmt.Fprintf(fm.buf, "func %s(%s) (%s) {\n", conv.name, strings.Join(params, ", "), strings.Join(results, ", "))
+	fm.buf.WriteString(body.String())
+	fmt.Fprintf(fm.buf, "return\n}\n\n")
+}
+
+// multiSelector 一つであれば src、複数であれば src0 src1 ...
+func multiSelector(name string, i, n int) string {
+	if n == 1 {
+		return name
+	}
+	return fmt.Sprintf("%s%d", name, i)
+}
+
+// feed 複数の dst を返す関数のコメントに、dst のフィールドに書き込んだ src のフィールドを記録する。
+func (fm *FuncMaker) feed(dstSelector, dField string, sf srcField) {
+	if fm.feeds == nil || dstSelector != fm.rootDst {
+		return
+	}
+	*fm.feeds = append(*fm.feeds, fmt.Sprintf("%s <- %s", dField, sf.selector))
+}
+
+// multiConverter 複数の型を変換する関数。ConvEventRoomToEventResponse
+func (fm *FuncMaker) multiConverter(dstTypes, srcTypes []Type) (converter, error) {
+	srcNames, srcInfos, srcPairs, err := fm.multiNames(srcTypes)
+	if err != nil {
+		return converter{}, err
+	}
+	dstNames, dstInfos, dstPairs, err := fm.multiNames(dstTypes)
+	if err != nil {
+		return converter{}, err
+	}
+
+	name := applyVisibility(fmt.Sprintf("Conv%sTo%s", strings.Join(srcNames, ""), strings.Join(dstNames, "")))
+	if FuncName != nil {
+		name, err = execNameTemplate(FuncName, NameData{Src: srcInfos[0], Dst: dstInfos[0], Srcs: srcInfos, Dsts: dstInfos})
+		if err != nil {
+			fm.addError(err)
+			return converter{}, err
+		}
+	}
+	pair := fmt.Sprintf("%s -> %s", strings.Join(srcPairs, ", "), strings.Join(dstPairs, ", "))
+	conv, err := fm.names.register(pair, converter{name: name})
+	if err != nil {
+		fm.addError(err)
+	}
+	return conv, err
+}
+
+// multiNames 関数名に使う型名、テンプレートに渡す情報、型の組の名前
+func (fm *FuncMaker) multiNames(ts []Type) (names []string, infos []NameInfo, pairs []string, err error) {
+	for _, t := range ts {
+		name, err := fm.formatPkgType(t.typ)
+		if err != nil {
+			fm.addError(err)
+			return nil, nil, nil, err
+		}
+		names = append(names, funcTypeName(name))
+		infos = append(infos, fm.nameInfo(t.typ))
+		pairs = append(pairs, types.TypeString(t.typ, nil))
+	}
+	return
+}
+
+// sourceFieldName struct で無い src を、dst のフィールドと対応させる名前。
+// []User と map[string]User は Users、*Room は Room
+func sourceFieldName(t types.Type) string {
+	plural := false
+	for {
+		switch tt := t.(type) {
+		case *types.Pointer:
+			t = tt.Elem()
+			continue
+		case *types.Slice:
+			plural = true
+			t = tt.Elem()
+			continue
+		case *types.Array:
+			plural = true
+			t = tt.Elem()
+			continue
+		case *types.Map:
+			plural = true
+			t = tt.Elem()
+			continue
+		}
+		break
+	}
+
+	name := t.String()
+	if namedT, ok := t.(*types.Named); ok {
+		name = namedT.Obj().Name()
+	}
+	if plural {
+		name += "s"
+	}
+	return name
+}
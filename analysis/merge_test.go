@@ -0,0 +1,80 @@
+package analysis
+
+import (
+	"go/types"
+	"testing"
+)
+
+func Test_sourceFieldName(t *testing.T) {
+	user := types.NewNamed(types.NewTypeName(0, types.NewPackage("example.com/db", "db"), "User", nil), types.NewStruct(nil, nil), nil)
+	tests := []struct {
+		typ  types.Type
+		want string
+	}{
+		{typ: user, want: "User"},
+		{typ: types.NewPointer(user), want: "User"},
+		{typ: types.NewSlice(user), want: "Users"},
+		{typ: types.NewSlice(types.NewPointer(user)), want: "Users"},
+		{typ: types.NewMap(types.Typ[types.String], user), want: "Users"},
+		{typ: types.Typ[types.String], want: "string"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.typ.String(), func(t *testing.T) {
+			if got := sourceFieldName(tt.typ); got != tt.want {
+				t.Errorf("sourceFieldName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_srcField_from(t *testing.T) {
+	room := types.NewTypeName(0, types.NewPackage("example.com/db", "db"), "Room", nil)
+	tests := []struct {
+		tag    string
+		source *types.TypeName
+		field  string
+		want   bool
+	}{
+		{tag: "Name", source: room, field: "Name", want: true},
+		{tag: "Room:Name", source: room, field: "Name", want: true},
+		{tag: "db.Room:Name", source: room, field: "Name", want: true},
+		{tag: "Event:Name", source: room, field: "Name", want: false},
+		{tag: "Room:Name", source: nil, field: "Name", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			source, field := splitSource(tt.tag)
+			if field != tt.field {
+				t.Fatalf("splitSource() field = %v, want %v", field, tt.field)
+			}
+			if got := (srcField{source: tt.source}).from(source); got != tt.want {
+				t.Errorf("from() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_srcField_to(t *testing.T) {
+	pkg := types.NewPackage("example.com/domain", "domain")
+	room := types.NewNamed(types.NewTypeName(0, pkg, "Room", nil), types.NewStruct(nil, nil), nil)
+	event := types.NewNamed(types.NewTypeName(0, pkg, "Event", nil), types.NewStruct(nil, nil), nil)
+	tests := []struct {
+		target string
+		dst    types.Type
+		want   bool
+	}{
+		{target: "", dst: room, want: true},
+		{target: "", dst: types.NewStruct(nil, nil), want: true},
+		{target: "Room", dst: room, want: true},
+		{target: "domain.Room", dst: room, want: true},
+		{target: "Room", dst: event, want: false},
+		{target: "Room", dst: types.NewStruct(nil, nil), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target+"->"+tt.dst.String(), func(t *testing.T) {
+			if got := (srcField{target: tt.target}).to(tt.dst); got != tt.want {
+				t.Errorf("to() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
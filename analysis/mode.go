@@ -0,0 +1,101 @@
+package analysis
+
+import (
+	"fmt"
+	"go/types"
+	"strings"
+)
+
+// ConvMode 生成する関数の形
+type ConvMode int
+
+const (
+	// ModeReturn func ConvXToY(src X) (dst Y)
+	ModeReturn ConvMode = iota
+	// ModeInto func ConvXIntoY(src *X, dst *Y)。対応しない dst のフィールドは変更しない
+	ModeInto
+	// ModeApply func ApplyXToY(src X, dst *Y)。src が nil、ゼロ値のフィールドは書き込まない
+	ModeApply
+)
+
+// Mode 生成する関数の形
+var Mode = ModeReturn
+
+// ParseConvMode parses the value of the -mode flag.
+func ParseConvMode(s string) (ConvMode, error) {
+	switch s {
+	case "", "return":
+		return ModeReturn, nil
+	case "into":
+		return ModeInto, nil
+	case "apply":
+		return ModeApply, nil
+	}
+	return ModeReturn, fmt.Errorf("unknown mode %q", s)
+}
+
+// inPlace 既にある dst に書き込むか
+func inPlace() bool {
+	return Mode == ModeInto || Mode == ModeApply
+}
+
+// funcPrefix funcVerb 関数名の src の前と、src と dst の間に入れる言葉
+func funcPrefix() string {
+	if Mode == ModeApply {
+		return "Apply"
+	}
+	return "Conv"
+}
+
+func funcVerb() string {
+	if Mode == ModeInto {
+		return "Into"
+	}
+	return "To"
+}
+
+// addressable selector のアドレスを取れるか。関数呼び出しの結果は取れない。
+func addressable(selector string) bool {
+	if !strings.HasSuffix(selector, ")") {
+		return true
+	}
+	if !strings.HasPrefix(selector, "(*") {
+		return false
+	}
+	// (*x) の括弧が最後まで続いているか
+	depth := 0
+	for i, r := range selector {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i == len(selector)-1
+			}
+		}
+	}
+	return false
+}
+
+// allocPointer ポインタの dst を確保する文。
+// ModeInto ModeApply の場合は、既に指している先に書き込む。
+func allocPointer(selector, typeName string) string {
+	if inPlace() {
+		return fmt.Sprintf("if %s == nil {\n%s = new(%s)\n}\n", selector, selector, typeName)
+	}
+	return fmt.Sprintf("%s = new(%s)\n", selector, typeName)
+}
+
+// applyGuard ModeApply で、src がゼロ値で無いときだけ書き込むための条件。
+// スライスの要素と、nil を確認した後の値には付けない。
+func (fm *FuncMaker) applyGuard(t types.Type, srcSelector, index string) (string, bool) {
+	if Mode != ModeApply || fm.noGuard || index != "" {
+		return "", false
+	}
+	zero, err := fm.zeroValue(t)
+	if err != nil {
+		return "", false
+	}
+	return fmt.Sprintf("%s != %s", srcSelector, zero), true
+}
@@ -0,0 +1,33 @@
+package analysis
+
+import "testing"
+
+func Test_addrSelector(t *testing.T) {
+	tests := []struct {
+		selector    string
+		addressable bool
+		want        string
+	}{
+		{selector: "dst.X", addressable: true, want: "&dst.X"},
+		{selector: "src.X[i]", addressable: true, want: "&src.X[i]"},
+		{selector: "(*dst)", addressable: true, want: "dst"},
+		{selector: "(*src.X)", addressable: true, want: "src.X"},
+		{selector: "(*src.X).Y", addressable: true, want: "&(*src.X).Y"},
+		{selector: "src.GetX()", addressable: false},
+		{selector: "db.ConvGetEventID(src)", addressable: false},
+		{selector: "(*src).GetX()", addressable: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			if got := addressable(tt.selector); got != tt.addressable {
+				t.Fatalf("addressable() = %v, want %v", got, tt.addressable)
+			}
+			if !tt.addressable {
+				return
+			}
+			if got := addrSelector(tt.selector); got != tt.want {
+				t.Errorf("addrSelector() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package analysis
+
+import (
+	"bytes"
+	"fmt"
+	"go/types"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// VisibilityMode 生成する関数名を公開するか
+type VisibilityMode int
+
+const (
+	// VisibilityKeep テンプレートの通り
+	VisibilityKeep VisibilityMode = iota
+	// VisibilityExported 先頭を大文字にする
+	VisibilityExported
+	// VisibilityUnexported 先頭を小文字にする
+	VisibilityUnexported
+)
+
+// CollisionMode 異なる型の組が同じ名前になったときの扱い
+type CollisionMode int
+
+const (
+	// CollisionSuffix 後から生成する方に 2, 3, ... を付ける
+	CollisionSuffix CollisionMode = iota
+	// CollisionError エラーにする
+	CollisionError
+)
+
+var (
+	// FuncName 関数名のテンプレート。nil の場合は Conv${src}To${dst} (パッケージ名と型名を繋げる)
+	FuncName *template.Template
+	// Visibility 生成する関数名の公開
+	Visibility = VisibilityKeep
+	// Collision 名前が衝突したときの扱い
+	Collision = CollisionSuffix
+)
+
+// ParseVisibilityMode parses the value of the -visibility flag.
+func ParseVisibilityMode(s string) (VisibilityMode, error) {
+	switch s {
+	case "", "keep":
+		return VisibilityKeep, nil
+	case "exported":
+		return VisibilityExported, nil
+	case "unexported":
+		return VisibilityUnexported, nil
+	}
+	return VisibilityKeep, fmt.Errorf("unknown visibility %q", s)
+}
+
+// ParseCollisionMode parses the value of the -collision flag.
+func ParseCollisionMode(s string) (CollisionMode, error) {
+	switch s {
+	case "", "suffix":
+		return CollisionSuffix, nil
+	case "error":
+		return CollisionError, nil
+	}
+	return CollisionSuffix, fmt.Errorf("unknown collision mode %q", s)
+}
+
+// NameInfo 名前のテンプレートに渡す型の情報
+type NameInfo struct {
+	// Pkg パッケージ名。basic の場合は ""
+	Pkg string
+	// Name 型名
+	Name string
+	// Local 出力するパッケージの型か
+	Local bool
+	// Pointer ポインタか
+	Pointer bool
+	// Slice スライスか
+	Slice bool
+	// Map マップか。Name は要素の型名
+	Map bool
+}
+
+// NameData 名前のテンプレートに渡す値
+type NameData struct {
+	Src, Dst NameInfo
+	// Srcs Dsts -s -d に複数の型を指定した場合の、全ての src dst
+	Srcs, Dsts []NameInfo
+	// Into -mode into の関数か
+	Into bool
+	// Apply -mode apply の関数か
+	Apply bool
+}
+
+var nameFuncs = template.FuncMap{
+	"title": func(s string) string {
+		if s == "" {
+			return s
+		}
+		return strings.ToUpper(s[:1]) + s[1:]
+	},
+	"lower": func(s string) string {
+		if s == "" {
+			return s
+		}
+		return strings.ToLower(s[:1]) + s[1:]
+	},
+}
+
+// ParseNameTemplate 名前のテンプレートを parse する。
+// {{.Src.Pkg}} {{.Src.Name}} {{.Src.Local}} {{.Src.Pointer}} {{.Src.Slice}} {{.Src.Map}} (Dst も同様)、{{.Srcs}} {{.Dsts}}、{{.Into}} {{.Apply}} と、title lower が使える。
+func ParseNameTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(nameFuncs).Option("missingkey=error").Parse(text)
+}
+
+var identRe = regexp.MustCompile(`^[\p{L}_][\p{L}\p{N}_]*$`)
+
+func execNameTemplate(tmpl *template.Template, data NameData) (string, error) {
+	buf := new(bytes.Buffer)
+	if err := tmpl.Execute(buf, data); err != nil {
+		return "", err
+	}
+	name := applyVisibility(buf.String())
+	if !identRe.MatchString(name) {
+		return "", fmt.Errorf("template %s: %q is not an identifier", tmpl.Name(), name)
+	}
+	return name, nil
+}
+
+func applyVisibility(name string) string {
+	if name == "" {
+		return name
+	}
+	switch Visibility {
+	case VisibilityExported:
+		return strings.ToUpper(name[:1]) + name[1:]
+	case VisibilityUnexported:
+		return strings.ToLower(name[:1]) + name[1:]
+	}
+	return name
+}
+
+func (fm *FuncMaker) nameInfo(t types.Type) NameInfo {
+	var info NameInfo
+	for {
+		switch tt := t.(type) {
+		case *types.Pointer:
+			info.Pointer = true
+			t = tt.Elem()
+			continue
+		case *types.Slice:
+			info.Slice = true
+			t = tt.Elem()
+			continue
+		case *types.Map:
+			info.Map = true
+			t = tt.Elem()
+			continue
+		case *types.Named:
+			info.Name = tt.Obj().Name()
+			if tt.Obj().Pkg() != nil {
+				info.Pkg = tt.Obj().Pkg().Name()
+				info.Local = fm.samePkg(tt.Obj().Pkg())
+			}
+		default:
+			info.Name = t.String()
+		}
+		return info
+	}
+}
+
+// nameTable 生成する関数の名前と型の組。全ての FuncMaker で共有する
+type nameTable struct {
+	// pairs 型の組から関数
+	pairs map[string]converter
+	// names 関数から型の組
+	names map[string]string
+	// fallible error も返す関数
+	fallible map[string]bool
+}
+
+func newNameTable() *nameTable {
+	return &nameTable{
+		pairs:    map[string]converter{},
+		names:    map[string]string{},
+		fallible: map[string]bool{},
+	}
+}
+
+// register 型の組に名前を割り当てる。
+// 既に別の型の組で使われている名前であれば、suffix を付けるかエラーにする。
+func (nt *nameTable) register(pair string, conv converter) (converter, error) {
+	if other, ok := nt.names[conv.key()]; ok {
+		if Collision == CollisionError {
+			return conv, fmt.Errorf("%s is generated for both %s and %s: change the name template or use -collision suffix",
+				conv.key(), other, pair)
+		}
+		for i := 2; ; i++ {
+			c := conv
+			c.name = fmt.Sprintf("%s%d", conv.name, i)
+			if _, ok := nt.names[c.key()]; !ok {
+				conv = c
+				break
+			}
+		}
+	}
+	nt.pairs[pair] = conv
+	nt.names[conv.key()] = pair
+	return conv, nil
+}
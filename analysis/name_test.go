@@ -0,0 +1,55 @@
+package analysis
+
+import "testing"
+
+func Test_nameTable_register(t *testing.T) {
+	defer func() {
+		Collision = CollisionSuffix
+	}()
+
+	nt := newNameTable()
+	for i, pair := range []string{"a.bC -> X", "ab.C -> X", "ab.C2 -> X"} {
+		name := "ConvabCToX"
+		if i == 2 {
+			name = "ConvabC2ToX"
+		}
+		if _, err := nt.register(pair, converter{name: name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := map[string]string{
+		"a.bC -> X":  "ConvabCToX",
+		"ab.C -> X":  "ConvabCToX2",
+		"ab.C2 -> X": "ConvabC2ToX",
+	}
+	for pair, name := range want {
+		if got := nt.pairs[pair].name; got != name {
+			t.Errorf("register(%s) = %s, want %s", pair, got, name)
+		}
+	}
+
+	// メソッドはレシーバが異なれば衝突しない
+	if conv, _ := nt.register("A -> B", converter{name: "ConvabCToX", recv: "A"}); conv.name != "ConvabCToX" {
+		t.Errorf("register(A -> B) = %s, want ConvabCToX", conv.name)
+	}
+
+	Collision = CollisionError
+	if _, err := nt.register("abc.C -> X", converter{name: "ConvabCToX"}); err == nil {
+		t.Error("register() want error")
+	}
+}
+
+func Test_funcTypeName(t *testing.T) {
+	tests := map[string]string{
+		"db.Event":              "dbEvent",
+		"[]*db.Event":           "SPdbEvent",
+		"map[string]db.Event":   "MapstringdbEvent",
+		"map[db.ID][]*db.Event": "MapdbIDSPdbEvent",
+		"[3]int":                "A3int",
+	}
+	for name, want := range tests {
+		if got := funcTypeName(name); got != want {
+			t.Errorf("funcTypeName(%s) = %s, want %s", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package analysis
+
+import (
+	"fmt"
+	"go/types"
+)
+
+// nullField Valid と値のフィールドだけを持つ struct の、値のフィールド。
+// sql.NullString, sql.NullTime, gorm.DeletedAt など
+func (fm *FuncMaker) nullField(t types.Type) (value, valid *types.Var, ok bool) {
+	st, ok := t.Underlying().(*types.Struct)
+	if !ok || st.NumFields() != 2 {
+		return nil, nil, false
+	}
+	for i := 0; i < 2; i++ {
+		v, f := st.Field(i), st.Field(1-i)
+		if v.Name() != "Valid" || !types.Identical(v.Type(), types.Typ[types.Bool]) {
+			continue
+		}
+		if !fm.varVisiable(v) || !fm.varVisiable(f) || f.Embedded() {
+			return nil, nil, false
+		}
+		return f, v, true
+	}
+	return nil, nil, false
+}
+
+// nullTarget Valid と値の struct と変換する型か。
+// struct は、値と同じ型のときだけ（time.Time など）
+func nullTarget(t types.Type, value *types.Var) bool {
+	if pt, ok := t.Underlying().(*types.Pointer); ok {
+		t = pt.Elem()
+	}
+	if _, ok := t.Underlying().(*types.Struct); !ok {
+		return true
+	}
+	return types.Identical(t, value.Type())
+}
+
+// nullAndOther Valid と値の struct と、値・ポインタを変換する。
+// 変換する組で無ければ ok は false
+func (fm *FuncMaker) nullAndOther(dst, src Type, dstSelector, srcSelector, index string, history [][2]types.Type) (written, ok bool) {
+	dValue, dValid, dok := fm.nullField(dst.typ)
+	sValue, sValid, sok := fm.nullField(src.typ)
+	switch {
+	case sok && !dok && nullTarget(dst.typ, sValue):
+		return fm.fromNull(dst, sValue, sValid, dstSelector, srcSelector, index, history), true
+	case dok && !sok && nullTarget(src.typ, dValue):
+		return fm.toNull(dValue, dValid, src, dstSelector, srcSelector, index, history), true
+	}
+	return false, false
+}
+
+// fromNull Valid のときだけ値を変換する。Valid でなければ、ポインタは nil、値はゼロ値
+func (fm *FuncMaker) fromNull(dst Type, value, valid *types.Var, dstSelector, srcSelector, index string, history [][2]types.Type) bool {
+	return fm.deferWrite(func(tmpFm *FuncMaker) bool {
+		id := tmpFm.openGuard(selectorGen(srcSelector, valid))
+		tmpFm.noGuard = true
+		written := tmpFm.makeFunc(dst, Type{typ: value.Type()}, dstSelector, selectorGen(srcSelector, value), index, history)
+
+		zero, err := tmpFm.zeroValue(dst.typ)
+		if Mode == ModeInto && err == nil {
+			tmpFm.closeGuardElse(id, fmt.Sprintf("%s = %s\n", dstSelector, zero))
+		} else {
+			tmpFm.closeGuard(id)
+		}
+		return written
+	})
+}
+
+// toNull 値を変換して Valid にする。ポインタは nil でなければ
+func (fm *FuncMaker) toNull(value, valid *types.Var, src Type, dstSelector, srcSelector, index string, history [][2]types.Type) bool {
+	return fm.deferWrite(func(tmpFm *FuncMaker) bool {
+		guard, ok := tmpFm.applyGuard(src.typ, srcSelector, index)
+		if pt, isPointer := src.typ.Underlying().(*types.Pointer); isPointer {
+			guard, ok = fmt.Sprintf("%s != nil", srcSelector), true
+			src, srcSelector = Type{typ: pt.Elem()}, fmt.Sprintf("(*%s)", srcSelector)
+		}
+		id := 0
+		if ok {
+			id = tmpFm.openGuard(guard)
+			tmpFm.noGuard = true
+		}
+
+		written := tmpFm.makeFunc(Type{typ: value.Type()}, src, selectorGen(dstSelector, value), srcSelector, index, history)
+		fmt.Fprintf(tmpFm.buf, "%s = true\n", selectorGen(dstSelector, valid))
+
+		if ok {
+			if Mode == ModeInto {
+				tmpFm.closeGuardElse(id, fmt.Sprintf("%s = false\n", selectorGen(dstSelector, valid)))
+			} else {
+				tmpFm.closeGuard(id)
+			}
+		}
+		if written {
+			tmpFm.dstWrittenSelector[dstSelector] = struct{}{}
+		}
+		return written
+	})
+}
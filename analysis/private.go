@@ -0,0 +1,213 @@
+package analysis
+
+import (
+	"fmt"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// PrivateMode 出力するパッケージから見えないフィールドの扱い
+type PrivateMode int
+
+const (
+	// PrivateSkip 見えないフィールドは無視する
+	PrivateSkip PrivateMode = iota
+	// PrivateError 対応するフィールドが見えないときはエラーにする
+	PrivateError
+	// PrivateSplit フィールドを持つパッケージに、読み書きする関数を生成する
+	PrivateSplit
+)
+
+// Private 見えないフィールドの扱い
+var Private = PrivateSkip
+
+// ParsePrivateMode parses the value of the -private flag.
+func ParsePrivateMode(s string) (PrivateMode, error) {
+	switch s {
+	case "", "skip":
+		return PrivateSkip, nil
+	case "error":
+		return PrivateError, nil
+	case "split":
+		return PrivateSplit, nil
+	}
+	return PrivateSkip, fmt.Errorf("unknown private mode %q", s)
+}
+
+// privateField 見えないフィールドと、それを持つ型
+type privateField struct {
+	owner *types.Named
+	field *types.Var
+}
+
+// privateOwner 他のパッケージの named type であれば返す。
+func (fm *FuncMaker) privateOwner(t types.Type) (*types.Named, bool) {
+	namedT, ok := t.(*types.Named)
+	if !ok || namedT.Obj().Pkg() == nil || fm.samePkg(namedT.Obj().Pkg()) {
+		return nil, false
+	}
+	return namedT, true
+}
+
+func (pf privateField) String() string {
+	return fmt.Sprintf("%s.%s.%s", pf.owner.Obj().Pkg().Name(), pf.owner.Obj().Name(), pf.field.Name())
+}
+
+func (pf privateField) helperName(prefix string) string {
+	name := pf.field.Name()
+	return fmt.Sprintf("Conv%s%s%s%s", prefix, pf.owner.Obj().Name(), strings.ToUpper(name[:1]), name[1:])
+}
+
+// splittable helper で読み書き出来るか。フィールドの型が見えない場合は出来ない。
+func (fm *FuncMaker) splittable(pf privateField) bool {
+	if Private != PrivateSplit {
+		return false
+	}
+	return fm.typeVisible(pf.field.Type())
+}
+
+// typeVisible 型に含まれる named type が全て見えるか
+func (fm *FuncMaker) typeVisible(t types.Type) bool {
+	visible := true
+	var inspect func(t types.Type)
+	inspect = func(t types.Type) {
+		switch t := t.(type) {
+		case *types.Named:
+			if !fm.typeNameVisiable(t.Obj()) {
+				visible = false
+			}
+		case *types.Pointer:
+			inspect(t.Elem())
+		case *types.Slice:
+			inspect(t.Elem())
+		case *types.Array:
+			inspect(t.Elem())
+		case *types.Map:
+			inspect(t.Key())
+			inspect(t.Elem())
+		}
+	}
+	inspect(t)
+	return visible
+}
+
+// privateGetter helper を使って読み込む式
+func (fm *FuncMaker) privateGetter(pf privateField, srcSelector string) string {
+	return fmt.Sprintf("%s(%s)", fm.formatPkgString(pf.owner.Obj().Pkg().Path()+"."+pf.helperName("Get")), srcSelector)
+}
+
+// privateSetter helper を使って書き込む関数
+func (fm *FuncMaker) privateSetter(pf privateField) string {
+	return fm.formatPkgString(pf.owner.Obj().Pkg().Path() + "." + pf.helperName("Set"))
+}
+
+// addPrivateHelper フィールドを持つパッケージに、読み書きする関数を追加する。
+// 関数はまだ無く goimports では解決できないので、パッケージを import する
+func (fm *FuncMaker) addPrivateHelper(pf privateField, set bool) {
+	pkg := pf.owner.Obj().Pkg()
+	fm.importPkg(pkg)
+	qualifier := func(p *types.Package) string {
+		if p.Path() == pkg.Path() {
+			return ""
+		}
+		return p.Name()
+	}
+	typeName := pf.owner.Obj().Name()
+	fieldType := types.TypeString(pf.field.Type(), qualifier)
+
+	var name, code string
+	if set {
+		name = pf.helperName("Set")
+		code = fmt.Sprintf("// %s sets %s.%s for gotypeconverter.\nfunc %s(dst *%s, v %s) {\ndst.%s = v\n}\n\n",
+			name, typeName, pf.field.Name(), name, typeName, fieldType, pf.field.Name())
+	} else {
+		name = pf.helperName("Get")
+		code = fmt.Sprintf("// %s returns %s.%s for gotypeconverter.\nfunc %s(src %s) %s {\nreturn src.%s\n}\n\n",
+			name, typeName, pf.field.Name(), name, typeName, fieldType, pf.field.Name())
+	}
+
+	if fm.helpers[pkg] == nil {
+		fm.helpers[pkg] = map[string]string{}
+	}
+	fm.helpers[pkg][name] = code
+}
+
+// recordPrivate 対応するフィールドが見えなかったことを記録する。
+func (fm *FuncMaker) recordPrivate(pf privateField) {
+	if Private != PrivateError {
+		return
+	}
+	fm.privates[pf.String()] = pf.owner.Obj().Pkg().Path()
+}
+
+// checkPrivateDst 見えない dst のフィールドに対応する src のフィールドがあれば記録する。
+func (fm *FuncMaker) checkPrivateDst(dstT TypeStruct, i int, sFields []srcField) {
+	owner, ok := fm.privateOwner(dstT.orig)
+	if !ok || Private != PrivateError {
+		return
+	}
+	name, _, writeName, option := getTag(dstT.typ.Tag(i))
+	if option == Ignore || option == ReadOnly {
+		return
+	}
+	if writeName != "" {
+		name = writeName
+	}
+	if name == "" {
+		name = dstT.typ.Field(i).Name()
+	}
+	for _, exact := range []bool{true, false} {
+		if !exact && !fuzzyMatch() {
+			break
+		}
+		for _, sf := range sFields {
+			if !sf.embedded && matchName(name, sf.name, exact) {
+				fm.recordPrivate(privateField{owner: owner, field: dstT.typ.Field(i)})
+				return
+			}
+		}
+	}
+}
+
+// privateError 見えないフィールドがあれば、どこに生成すれば良いかを含めたエラーを返す。
+func (fm *FuncMaker) privateError() error {
+	if len(fm.privates) == 0 {
+		return nil
+	}
+	fields := make([]string, 0, len(fm.privates))
+	pkgSet := map[string]struct{}{}
+	for field, pkg := range fm.privates {
+		fields = append(fields, field)
+		pkgSet[pkg] = struct{}{}
+	}
+	sort.Strings(fields)
+	pkgs := make([]string, 0, len(pkgSet))
+	for pkg := range pkgSet {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Strings(pkgs)
+
+	if len(pkgs) == 1 {
+		return fmt.Errorf("unexported fields %s cannot be accessed from package %s: generate into package %s or use -private split",
+			strings.Join(fields, ", "), fm.pkg.Path(), pkgs[0])
+	}
+	return fmt.Errorf("unexported fields %s belong to packages %s and no single package can access all of them: use -private split",
+		strings.Join(fields, ", "), strings.Join(pkgs, ", "))
+}
+
+// Helpers パッケージごとに、見えないフィールドを読み書きする関数を返す。
+func (fm *FuncMaker) Helpers() map[*types.Package][]byte {
+	out := make(map[*types.Package][]byte, len(fm.helpers))
+	for pkg, funcs := range fm.helpers {
+		names := make([]string, 0, len(funcs))
+		for name := range funcs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			out[pkg] = append(out[pkg], funcs[name]...)
+		}
+	}
+	return out
+}
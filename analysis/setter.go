@@ -0,0 +1,258 @@
+package analysis
+
+import (
+	"fmt"
+	"go/types"
+	"strings"
+)
+
+var (
+	// Setter フィールドが見えないときに、SetX メソッドで書き込む
+	Setter = true
+	// Constructor dst の型の New<Type> 関数で dst を作る
+	Constructor = false
+)
+
+// dstSetter SetX(v) メソッド
+type dstSetter struct {
+	name   string
+	method *types.Func
+	param  types.Type
+	// 見えないフィールドに helper で書き込む
+	private *privateField
+}
+
+// setters 見えるフィールドが無い SetX(v) メソッドを返す。
+func (fm *FuncMaker) setters(dstT TypeStruct) []dstSetter {
+	if dstT.orig == nil {
+		return nil
+	}
+	if _, ok := dstT.orig.(*types.Named); !ok {
+		return nil
+	}
+
+	visible := map[string]struct{}{}
+	for i := 0; i < dstT.typ.NumFields(); i++ {
+		if fm.varVisiable(dstT.typ.Field(i)) {
+			visible[dstT.typ.Field(i).Name()] = struct{}{}
+		}
+	}
+
+	// dst は addressable なので、pointer receiver のメソッドも呼べる
+	mset := types.NewMethodSet(types.NewPointer(dstT.orig))
+	setters := make([]dstSetter, 0)
+	for i := 0; i < mset.Len() && Setter; i++ {
+		f, ok := mset.At(i).Obj().(*types.Func)
+		if !ok || !f.Exported() {
+			continue
+		}
+		name := f.Name()
+		if len(name) <= 3 || !strings.HasPrefix(name, "Set") {
+			continue
+		}
+		if _, ok := visible[name[3:]]; ok {
+			continue
+		}
+		sig := f.Type().(*types.Signature)
+		if sig.Params().Len() != 1 || sig.Results().Len() != 0 || sig.Variadic() {
+			continue
+		}
+		setters = append(setters, dstSetter{
+			name:   name[3:],
+			method: f,
+			param:  sig.Params().At(0).Type(),
+		})
+	}
+
+	owner, ok := fm.privateOwner(dstT.orig)
+	if !ok || Private != PrivateSplit {
+		return setters
+	}
+NEXT:
+	for i := 0; i < dstT.typ.NumFields(); i++ {
+		field := dstT.typ.Field(i)
+		pf := privateField{owner: owner, field: field}
+		if fm.varVisiable(field) || field.Embedded() || !fm.splittable(pf) {
+			continue
+		}
+		name, _, writeName, option := getTag(dstT.typ.Tag(i))
+		if option == Ignore || option == ReadOnly {
+			continue
+		}
+		if writeName != "" {
+			name = writeName
+		}
+		if name == "" {
+			name = field.Name()
+		}
+		// SetX メソッドがあれば、そちらを使う
+		for _, st := range setters {
+			if strings.EqualFold(st.name, field.Name()) {
+				continue NEXT
+			}
+		}
+		setters = append(setters, dstSetter{
+			name:    name,
+			param:   field.Type(),
+			private: &pf,
+		})
+	}
+	return setters
+}
+
+// setterAndOther 一時変数に変換してから、setter で書き込む。
+func (fm *FuncMaker) setterAndOther(st dstSetter, src Type, dstSelector, srcSelector, index string, history [][2]types.Type) bool {
+	var selector string
+	var call func(v string) string
+	if st.private != nil {
+		selector = selectorGen(dstSelector, st.private.field)
+		call = func(v string) string {
+			return fmt.Sprintf("%s(%s, %s)", fm.privateSetter(*st.private), addrSelector(dstSelector), v)
+		}
+	} else {
+		selector = fmt.Sprintf("%s.%s()", derefSelector(dstSelector), st.method.Name())
+		call = func(v string) string {
+			return fmt.Sprintf("%s.%s(%s)", derefSelector(dstSelector), st.method.Name(), v)
+		}
+	}
+	if fm.dstWritten(selector) {
+		return false
+	}
+
+	vars := *fm.tmpVars
+	written := fm.deferWrite(func(tmpFm *FuncMaker) bool {
+		pt, err := tmpFm.formatPkgType(st.param)
+		if err != nil {
+			return false
+		}
+		v := tmpFm.newVar()
+		// ModeApply では、一時変数ではなく setter の呼び出しを確認する
+		if guard, ok := tmpFm.applyGuard(src.typ, srcSelector, index); ok {
+			fmt.Fprintf(tmpFm.buf, "if %s ", guard)
+		}
+		tmpFm.noGuard = true
+		fmt.Fprintf(tmpFm.buf, "{\nvar %s %s\n", v, pt)
+		written := tmpFm.makeFunc(Type{typ: st.param}, src, v, srcSelector, index, history)
+		fmt.Fprintf(tmpFm.buf, "%s\n}\n", call(v))
+		if written {
+			tmpFm.dstWrittenSelector[selector] = struct{}{}
+		}
+		return written
+	})
+	if written && st.private != nil {
+		fm.addPrivateHelper(*st.private, true)
+	}
+	if written && st.private == nil {
+		fm.importPkg(st.method.Pkg())
+	}
+	if !written {
+		// 書き込まなかった一時変数は使い回す
+		*fm.tmpVars = vars
+	}
+	return written
+}
+
+// lookupConstructor dst の型と同じパッケージにある New<Type> 関数を返す。
+// 戻り値は Type か *Type のみ。
+func (fm *FuncMaker) lookupConstructor(dst types.Type) (*types.Func, bool) {
+	namedT, ok := dst.(*types.Named)
+	if !ok || namedT.Obj().Pkg() == nil {
+		return nil, false
+	}
+	obj := namedT.Obj().Pkg().Scope().Lookup("New" + namedT.Obj().Name())
+	f, ok := obj.(*types.Func)
+	if !ok || (!fm.samePkg(f.Pkg()) && !f.Exported()) {
+		return nil, false
+	}
+	sig := f.Type().(*types.Signature)
+	if sig.Results().Len() != 1 || sig.Variadic() {
+		return nil, false
+	}
+	res := sig.Results().At(0).Type()
+	if types.Identical(res, dst) {
+		return f, false
+	}
+	if p, ok := res.(*types.Pointer); ok && types.Identical(p.Elem(), dst) {
+		return f, true
+	}
+	return nil, false
+}
+
+// constructor New<Type> の引数名と src のフィールド名を対応させて、dst を作る。
+// 全ての引数に代入できないときは、何もしない。
+func (fm *FuncMaker) constructor(dst, src Type, dstSelector, srcSelector string) bool {
+	srcT, ok := src.typ.Underlying().(*types.Struct)
+	if !ok {
+		return false
+	}
+	return fm.constructorFields(dst, fm.srcFields(TypeStruct{typ: srcT, name: src.name, orig: src.typ}, srcSelector), dstSelector)
+}
+
+// constructorFields New<Type> の引数に sFields を渡して、dst を作る。
+func (fm *FuncMaker) constructorFields(dst Type, sFields []srcField, dstSelector string) bool {
+	// ModeInto ModeApply の場合は、既にある dst に書き込む
+	if !Constructor || inPlace() {
+		return false
+	}
+	f, isPointer := fm.lookupConstructor(dst.typ)
+	if f == nil {
+		return false
+	}
+
+	vars := *fm.tmpVars
+	written := fm.deferWrite(func(tmpFm *FuncMaker) bool {
+		params := f.Type().(*types.Signature).Params()
+		args := make([]string, params.Len())
+
+		fmt.Fprintf(tmpFm.buf, "{\n")
+		for i := 0; i < params.Len(); i++ {
+			param := params.At(i)
+			pt, err := tmpFm.formatPkgType(param.Type())
+			if err != nil {
+				return false
+			}
+			args[i] = tmpFm.newVar()
+			fmt.Fprintf(tmpFm.buf, "var %s %s\n", args[i], pt)
+
+			written := false
+			for _, match := range []func(p, s string) bool{
+				func(p, s string) bool { return p == s },
+				strings.EqualFold,
+				func(p, s string) bool { return fuzzyMatch() && matchName(p, s, false) },
+			} {
+				for _, sf := range sFields {
+					if written || sf.embedded || sf.selector == "" || !match(param.Name(), sf.name) {
+						continue
+					}
+					written = tmpFm.makeFunc(Type{typ: param.Type()}, Type{typ: sf.typ}, args[i], sf.selector, "", nil)
+				}
+			}
+			if !written {
+				return false
+			}
+		}
+
+		call := fmt.Sprintf("%s(%s)", fm.formatPkgString(f.Pkg().Path()+"."+f.Name()), strings.Join(args, ", "))
+		if isPointer {
+			call = "*" + call
+		}
+		fmt.Fprintf(tmpFm.buf, "%s = %s\n}\n", dstSelector, call)
+
+		// 引数で渡した値は、setter で書き込まない
+		for i := 0; i < params.Len(); i++ {
+			name := params.At(i).Name()
+			if name == "" {
+				continue
+			}
+			setter := fmt.Sprintf("%s.Set%s%s()", derefSelector(dstSelector), strings.ToUpper(name[:1]), name[1:])
+			tmpFm.dstWrittenSelector[setter] = struct{}{}
+		}
+		return true
+	})
+	if written {
+		fm.importPkg(f.Pkg())
+	} else {
+		*fm.tmpVars = vars
+	}
+	return written
+}
@@ -0,0 +1,164 @@
+package analysis
+
+import (
+	"fmt"
+	"go/types"
+	"strconv"
+	"strings"
+)
+
+// SliceOption スライスとスライスでない型の変換方法
+type SliceOption int
+
+const (
+	// SliceDefault -sliceToScalar -scalarToSlice に従う
+	SliceDefault SliceOption = iota
+	// SliceNone 変換しない
+	SliceNone
+	// SliceFirst 最初の要素を使う `cvt:",first"`
+	SliceFirst
+	// SliceLast 最後の要素を使う `cvt:",last"`
+	SliceLast
+	// SliceJoin 文字列のスライスを繋げる `cvt:",join"` `cvt:",join:;"`
+	SliceJoin
+	// SliceWrap 要素が一つのスライスにする `cvt:",wrap"`
+	SliceWrap
+)
+
+var (
+	// SliceToScalar スライスからスライスでない型への変換方法
+	SliceToScalar = SliceFirst
+	// ScalarToSlice スライスでない型からスライスへの変換方法
+	ScalarToSlice = SliceWrap
+	// JoinSep SliceJoin の区切り文字
+	JoinSep = ","
+)
+
+var sliceOptions = map[string]SliceOption{
+	"none":  SliceNone,
+	"first": SliceFirst,
+	"last":  SliceLast,
+	"join":  SliceJoin,
+	"wrap":  SliceWrap,
+}
+
+// ParseSliceOption parses the value of the -sliceToScalar and -scalarToSlice flags.
+func ParseSliceOption(s string, allowed ...SliceOption) (SliceOption, error) {
+	option, ok := sliceOptions[s]
+	if ok {
+		for _, a := range allowed {
+			if option == a {
+				return option, nil
+			}
+		}
+	}
+	return SliceDefault, fmt.Errorf("unknown slice option %q", s)
+}
+
+// fieldSlice フィールドに指定された変換方法
+type fieldSlice struct {
+	option SliceOption
+	sep    string
+}
+
+// isSliceOption `cvt:",first"` などのオプションか
+func isSliceOption(tag string) bool {
+	_, ok := parseSliceOption(tag)
+	return ok
+}
+
+func parseSliceOption(tag string) (fieldSlice, bool) {
+	if strings.HasPrefix(tag, "join:") {
+		sep := tag[len("join:"):]
+		if s, err := strconv.Unquote(sep); err == nil {
+			sep = s
+		}
+		return fieldSlice{option: SliceJoin, sep: sep}, true
+	}
+	option, ok := sliceOptions[tag]
+	return fieldSlice{option: option, sep: JoinSep}, ok
+}
+
+// getSliceTag 構造体タグで指定された変換方法。tags の先に書かれたものを優先する。
+func getSliceTag(tags ...string) fieldSlice {
+	for _, tag := range tags {
+		cvtTag, err := parseTag(tag)
+		if err != nil {
+			continue
+		}
+		for _, option := range cvtTag.Options {
+			if fs, ok := parseSliceOption(strings.Trim(option, " ")); ok {
+				return fs
+			}
+		}
+	}
+	return fieldSlice{}
+}
+
+// toScalar スライスからスライスでない型への変換方法
+func (fs fieldSlice) toScalar() fieldSlice {
+	switch fs.option {
+	case SliceNone, SliceFirst, SliceLast, SliceJoin:
+		return fs
+	}
+	return fieldSlice{option: SliceToScalar, sep: JoinSep}
+}
+
+// toSlice スライスでない型からスライスへの変換方法
+func (fs fieldSlice) toSlice() SliceOption {
+	switch fs.option {
+	case SliceNone, SliceWrap:
+		return fs.option
+	}
+	return ScalarToSlice
+}
+
+func (fm *FuncMaker) sliceAndOther(dstT TypeSlice, src Type, dstSelector, srcSelector, index string, history [][2]types.Type) bool {
+	if fm.slice.toSlice() != SliceWrap {
+		return false
+	}
+	return fm.deferWrite(func(tmpFm *FuncMaker) bool {
+		dt, err := tmpFm.formatPkgType(dstT.typ)
+		if err != nil {
+			return false
+		}
+		fmt.Fprintf(tmpFm.buf, "%s = make(%s, 1)\n", dstSelector, dt)
+		return tmpFm.makeFunc(Type{typ: dstT.typ.Elem()}, src, dstSelector+"[0]", srcSelector, index, history)
+	})
+}
+
+func (fm *FuncMaker) otherAndSlice(dst Type, srcT TypeSlice, dstSelector, srcSelector, index string, history [][2]types.Type) bool {
+	fs := fm.slice.toScalar()
+	switch fs.option {
+	case SliceFirst:
+		if useCvtUtil() && types.Identical(dst.typ, srcT.typ.Elem()) && !fm.deepCopy(dst.typ) {
+			return fm.deferWrite(func(tmpFm *FuncMaker) bool {
+				fmt.Fprintf(tmpFm.buf, "%s = %s\n", dstSelector, tmpFm.cvtutil("FirstOrZero", srcSelector))
+				tmpFm.dstWrittenSelector[dstSelector] = struct{}{}
+				return true
+			})
+		}
+		return fm.deferWrite(func(tmpFm *FuncMaker) bool {
+			fmt.Fprintf(tmpFm.buf, "if len(%s)>0 {\n", srcSelector)
+			written := tmpFm.makeFunc(dst, Type{typ: srcT.typ.Elem()}, dstSelector, srcSelector+"[0]", index, history)
+			fmt.Fprintln(tmpFm.buf, "}")
+			return written
+		})
+	case SliceLast:
+		return fm.deferWrite(func(tmpFm *FuncMaker) bool {
+			fmt.Fprintf(tmpFm.buf, "if len(%s)>0 {\n", srcSelector)
+			written := tmpFm.makeFunc(dst, Type{typ: srcT.typ.Elem()}, dstSelector, fmt.Sprintf("%s[len(%s)-1]", srcSelector, srcSelector), index, history)
+			fmt.Fprintln(tmpFm.buf, "}")
+			return written
+		})
+	case SliceJoin:
+		// string のスライスのみ
+		if !types.Identical(srcT.typ.Elem(), types.Typ[types.String]) {
+			return false
+		}
+		return fm.deferWrite(func(tmpFm *FuncMaker) bool {
+			return tmpFm.makeFunc(dst, Type{typ: types.Typ[types.String]}, dstSelector, fmt.Sprintf("strings.Join(%s, %q)", srcSelector, fs.sep), index, history)
+		})
+	}
+	return false
+}
@@ -0,0 +1,234 @@
+package analysis
+
+import (
+	"fmt"
+	"go/types"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// StyleMode 変換を関数とメソッドのどちらで生成するか
+type StyleMode int
+
+const (
+	// StyleFunction ConvXToY(src X) (dst Y)
+	StyleFunction StyleMode = iota
+	// StyleMethod 出力するパッケージで宣言された型のメソッド (src X) ToY() (dst Y) または (dst *Y) FromX(src X)
+	StyleMethod
+)
+
+var (
+	// Style 変換の生成方法
+	Style = StyleFunction
+	// PairStyles 型の組ごとの生成方法。key は Src:Dst (Event:domain.Event)
+	PairStyles map[string]StyleMode
+	// MethodTo src をレシーバにするメソッド名
+	MethodTo = template.Must(ParseNameTemplate("methodTo", "To{{.Dst.Name}}"))
+	// MethodFrom dst をレシーバにするメソッド名
+	MethodFrom = template.Must(ParseNameTemplate("methodFrom", "From{{.Src.Name}}"))
+)
+
+// ParseStyleMode parses the value of the -style flag.
+func ParseStyleMode(s string) (StyleMode, error) {
+	switch s {
+	case "", "function":
+		return StyleFunction, nil
+	case "method":
+		return StyleMethod, nil
+	}
+	return StyleFunction, fmt.Errorf("unknown style %q", s)
+}
+
+// converter 生成する関数またはメソッド
+type converter struct {
+	name string
+	// recv メソッドのレシーバの型名。関数の場合は ""
+	recv string
+	// from dst のポインタをレシーバにするメソッドか
+	from bool
+	// into src と dst のポインタを受け取る関数か
+	into bool
+	// apply src と dst のポインタを受け取り、ゼロ値で無いものだけ書き込む関数か
+	apply bool
+	// graph visited map を受け取る関数か
+	graph bool
+	// err error も返す関数か
+	err bool
+}
+
+// key 生成する関数を区別する名前
+func (c converter) key() string {
+	if c.recv == "" {
+		return c.name
+	}
+	return c.recv + "." + c.name
+}
+
+// call 変換を呼び出す文
+func (c converter) call(dstSelector, srcSelector string) string {
+	lhs, expr := c.callExpr(dstSelector, srcSelector)
+	if c.err {
+		if lhs != "" {
+			lhs += ", "
+		}
+		return fmt.Sprintf("if %serr = %s; err != nil {\nreturn\n}", lhs, expr)
+	}
+	if lhs == "" {
+		return expr
+	}
+	return lhs + " = " + expr
+}
+
+// callExpr 変換を呼び出す式と、結果を代入する selector。結果が無い場合は ""
+func (c converter) callExpr(dstSelector, srcSelector string) (string, string) {
+	switch {
+	case c.into:
+		return "", fmt.Sprintf("%s(%s, %s)", c.name, addrSelector(srcSelector), addrSelector(dstSelector))
+	case c.apply:
+		return "", fmt.Sprintf("%s(%s, %s)", c.name, srcSelector, addrSelector(dstSelector))
+	case c.graph:
+		return dstSelector, fmt.Sprintf("%s(%s, visited)", c.name, srcSelector)
+	case c.recv == "":
+		return dstSelector, fmt.Sprintf("%s(%s)", c.name, srcSelector)
+	case c.from:
+		return "", fmt.Sprintf("%s.%s(%s)", derefSelector(dstSelector), c.name, srcSelector)
+	default:
+		return dstSelector, fmt.Sprintf("%s.%s()", srcSelector, c.name)
+	}
+}
+
+// header 関数の宣言と、dst src の selector
+func (c converter) header(dstName, srcName string) (string, string, string) {
+	errResult := ""
+	if c.err {
+		errResult = ", err error"
+	}
+	switch {
+	case c.into:
+		return fmt.Sprintf("func %s(src *%s, dst *%s)%s {\nif src == nil {\nreturn\n}\n", c.name, srcName, dstName, results(errResult)), "(*dst)", "(*src)"
+	case c.apply:
+		return fmt.Sprintf("func %s(src %s, dst *%s)%s {\n", c.name, srcName, dstName, results(errResult)), "(*dst)", "src"
+	case c.graph:
+		return fmt.Sprintf("func %s(src %s, visited %s) (dst %s%s) {\n", c.name, srcName, visitedType, dstName, errResult), "dst", "src"
+	case c.recv == "":
+		return fmt.Sprintf("func %s(src %s) (dst %s%s) {\n", c.name, srcName, dstName, errResult), "dst", "src"
+	case c.from:
+		return fmt.Sprintf("func (dst *%s) %s(src %s)%s {\n", dstName, c.name, srcName, results(errResult)), "(*dst)", "src"
+	default:
+		return fmt.Sprintf("func (src %s) %s() (dst %s%s) {\n", srcName, c.name, dstName, errResult), "dst", "src"
+	}
+}
+
+// results dst を返さない関数の結果。", err error" であれば " (err error)"
+func results(errResult string) string {
+	if errResult == "" {
+		return ""
+	}
+	return " (" + strings.TrimPrefix(errResult, ", ") + ")"
+}
+
+var derefRe = regexp.MustCompile(`^\(\*(\w+)\)$`)
+
+// derefSelector (*dst) を dst にする。フィールドやメソッドの selector はポインタのままで良い。
+func derefSelector(selector string) string {
+	if m := derefRe.FindStringSubmatch(selector); m != nil {
+		return m[1]
+	}
+	return selector
+}
+
+// addrSelector selector のアドレス。(*dst) (*dst.X) は dst dst.X にする。
+func addrSelector(selector string) string {
+	if strings.HasPrefix(selector, "(*") && addressable(selector) && strings.HasSuffix(selector, ")") {
+		return selector[2 : len(selector)-1]
+	}
+	return "&" + selector
+}
+
+// styleOf 型の組の生成方法
+func (fm *FuncMaker) styleOf(dstType, srcType types.Type) StyleMode {
+	if PairStyles != nil {
+		dstName, _ := fm.formatPkgType(dstType)
+		srcName, _ := fm.formatPkgType(srcType)
+		if style, ok := PairStyles[srcName+":"+dstName]; ok {
+			return style
+		}
+	}
+	return Style
+}
+
+// methodRecv メソッドを宣言できる型であれば返す。
+func (fm *FuncMaker) methodRecv(t types.Type) (*types.Named, bool) {
+	namedT, ok := t.(*types.Named)
+	if !ok || namedT.Obj().Pkg() == nil || !fm.samePkg(namedT.Obj().Pkg()) {
+		return nil, false
+	}
+	switch namedT.Underlying().(type) {
+	case *types.Pointer, *types.Interface:
+		return nil, false
+	}
+	return namedT, true
+}
+
+// methodName レシーバに同名のフィールドが無ければ、テンプレートからメソッド名を返す。
+func (fm *FuncMaker) methodName(tmpl *template.Template, recv *types.Named, data NameData) (string, bool) {
+	name, err := execNameTemplate(tmpl, data)
+	if err != nil {
+		fm.addError(err)
+		return "", false
+	}
+	obj, _, _ := types.LookupFieldOrMethod(recv, true, fm.pkg, name)
+	if _, ok := obj.(*types.Var); ok {
+		return "", false
+	}
+	return name, true
+}
+
+// getConverter 型の組を変換する関数またはメソッド。
+// 同じ型の組には、常に同じものを返す。
+func (fm *FuncMaker) getConverter(dstType, srcType types.Type) (converter, error) {
+	pair := fmt.Sprintf("%s -> %s", types.TypeString(srcType, nil), types.TypeString(dstType, nil))
+	if conv, ok := fm.names.pairs[pair]; ok {
+		return conv, nil
+	}
+	conv, err := fm.newConverter(dstType, srcType)
+	if err != nil {
+		return conv, err
+	}
+	conv, err = fm.names.register(pair, conv)
+	if err != nil {
+		fm.addError(err)
+	}
+	return conv, err
+}
+
+// newConverter メソッドを宣言できない場合は関数にする。
+func (fm *FuncMaker) newConverter(dstType, srcType types.Type) (converter, error) {
+	funcName, err := fm.getFuncName(dstType, srcType)
+	if Graph {
+		return converter{name: funcName, graph: true}, err
+	}
+	switch Mode {
+	case ModeInto:
+		return converter{name: funcName, into: true}, err
+	case ModeApply:
+		return converter{name: funcName, apply: true}, err
+	}
+	if err != nil || fm.styleOf(dstType, srcType) != StyleMethod {
+		return converter{name: funcName}, err
+	}
+
+	data := NameData{Src: fm.nameInfo(srcType), Dst: fm.nameInfo(dstType)}
+	if recv, ok := fm.methodRecv(srcType); ok {
+		if name, ok := fm.methodName(MethodTo, recv, data); ok {
+			return converter{name: name, recv: recv.Obj().Name()}, nil
+		}
+	}
+	if recv, ok := fm.methodRecv(dstType); ok {
+		if name, ok := fm.methodName(MethodFrom, recv, data); ok {
+			return converter{name: name, recv: recv.Obj().Name(), from: true}, nil
+		}
+	}
+	return converter{name: funcName}, nil
+}
@@ -0,0 +1,79 @@
+package analysis
+
+import "testing"
+
+func Test_execNameTemplate(t *testing.T) {
+	data := NameData{
+		Src: NameInfo{Pkg: "db", Name: "Event"},
+		Dst: NameInfo{Pkg: "domain", Name: "Event"},
+	}
+	tests := []struct {
+		name    string
+		text    string
+		want    string
+		wantErr bool
+	}{
+		{name: "default to", text: "To{{.Dst.Name}}", want: "ToEvent"},
+		{name: "title", text: "To{{title .Dst.Pkg}}", want: "ToDomain"},
+		{name: "lower", text: "{{lower .Src.Name}}To{{.Dst.Name}}", want: "eventToEvent"},
+		{name: "not identifier", text: "To{{.Dst.Pkg}}.{{.Dst.Name}}", wantErr: true},
+		{name: "unknown field", text: "To{{.Dst.Path}}", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := ParseNameTemplate(tt.name, tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := execNameTemplate(tmpl, data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("execNameTemplate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("execNameTemplate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_converter_call(t *testing.T) {
+	tests := []struct {
+		name string
+		conv converter
+		dst  string
+		want string
+	}{
+		{name: "function", conv: converter{name: "ConvSRCToDST"}, dst: "dst.X", want: "dst.X = ConvSRCToDST(src.X)"},
+		{name: "to", conv: converter{name: "ToDST", recv: "SRC"}, dst: "dst.X", want: "dst.X = src.X.ToDST()"},
+		{name: "from", conv: converter{name: "FromSRC", recv: "DST", from: true}, dst: "dst.X", want: "dst.X.FromSRC(src.X)"},
+		{name: "from pointer", conv: converter{name: "FromSRC", recv: "DST", from: true}, dst: "(*dst)", want: "dst.FromSRC(src.X)"},
+		{name: "function error", conv: converter{name: "ConvSRCToDST", err: true}, dst: "dst.X", want: "if dst.X, err = ConvSRCToDST(src.X); err != nil {\nreturn\n}"},
+		{name: "from error", conv: converter{name: "FromSRC", recv: "DST", from: true, err: true}, dst: "(*dst)", want: "if err = dst.FromSRC(src.X); err != nil {\nreturn\n}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.conv.call(tt.dst, "src.X"); got != tt.want {
+				t.Errorf("call() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_converter_header(t *testing.T) {
+	tests := []struct {
+		name string
+		conv converter
+		want string
+	}{
+		{name: "function", conv: converter{name: "ConvSRCToDST", err: true}, want: "func ConvSRCToDST(src SRC) (dst DST, err error) {\n"},
+		{name: "apply", conv: converter{name: "ApplySRCToDST", apply: true, err: true}, want: "func ApplySRCToDST(src SRC, dst *DST) (err error) {\n"},
+		{name: "from", conv: converter{name: "FromSRC", recv: "DST", from: true, err: true}, want: "func (dst *DST) FromSRC(src SRC) (err error) {\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _, _ := tt.conv.header("DST", "SRC"); got != tt.want {
+				t.Errorf("header() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
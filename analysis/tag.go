@@ -8,6 +8,10 @@ import (
 
 var StructTag = "cvt"
 
+// MatchTags StructTag が無いときに、名前として使う構造体タグ (json, db など)
+// 先に書かれたものを優先する。
+var MatchTags []string
+
 type OptionTag int
 
 const (
@@ -16,6 +20,60 @@ const (
 	WriteOnly
 )
 
+// ValueOption 定数・初期値の指定
+type ValueOption int
+
+const (
+	// Const 常に値を代入する `cvt:",const:1"`
+	Const ValueOption = iota + 1
+	// Default 対応するフィールドが無いときに値を代入する `cvt:",default:\"draft\""`
+	Default
+	// DefaultIfZero 代入した結果がゼロ値のときにも値を代入する `cvt:",ifzero:\"draft\""`
+	DefaultIfZero
+)
+
+// valueOptions `key:value` 形式のオプション
+var valueOptions = map[string]ValueOption{
+	"const":   Const,
+	"default": Default,
+	"ifzero":  DefaultIfZero,
+}
+
+func parseTag(tag string) (*structtag.Tag, error) {
+	tags, err := structtag.Parse(tag)
+	if err != nil {
+		return nil, err
+	}
+	return tags.Get(StructTag)
+}
+
+// isValueOption `key:value` 形式のオプションか
+func isValueOption(tag string) bool {
+	i := strings.Index(tag, ":")
+	if i < 0 {
+		return false
+	}
+	_, ok := valueOptions[tag[:i]]
+	return ok
+}
+
+// getValueTag 定数・初期値の指定を返す。value は Go の式。
+func getValueTag(tag string) (option ValueOption, value string) {
+	cvtTag, err := parseTag(tag)
+	if err != nil {
+		return
+	}
+	for _, tag := range cvtTag.Options {
+		tag = strings.Trim(tag, " ")
+		if !isValueOption(tag) {
+			continue
+		}
+		i := strings.Index(tag, ":")
+		return valueOptions[tag[:i]], tag[i+1:]
+	}
+	return
+}
+
 func getTag(tag string) (name, readName, writeName string, option OptionTag) {
 	tags, err := structtag.Parse(tag)
 	if err != nil {
@@ -23,11 +81,14 @@ func getTag(tag string) (name, readName, writeName string, option OptionTag) {
 	}
 	cvtTag, err := tags.Get(StructTag)
 	if err != nil {
+		name = getMatchTagName(tags)
 		return
 	}
 
-	for _, tag := range append(cvtTag.Options, cvtTag.Name) {
+	for i, tag := range append(cvtTag.Options, cvtTag.Name) {
 		tag = strings.Trim(tag, " ")
+		// first などはオプションのときのみ。名前としても使える
+		isOption := i < len(cvtTag.Options)
 
 		if strings.HasPrefix(tag, "read:") {
 			readName = tag[5:]
@@ -37,6 +98,9 @@ func getTag(tag string) (name, readName, writeName string, option OptionTag) {
 			writeName = tag[6:]
 			continue
 		}
+		if isValueOption(tag) || (isOption && (isSliceOption(tag) || isTimeOption(tag))) {
+			continue
+		}
 
 		switch tag {
 		case "-":
@@ -49,5 +113,20 @@ func getTag(tag string) (name, readName, writeName string, option OptionTag) {
 			name = tag
 		}
 	}
+	// cvt:",first" のように名前が無ければ、-matchTag の名前を使う
+	if name == "" {
+		name = getMatchTagName(tags)
+	}
 	return
 }
+
+func getMatchTagName(tags *structtag.Tags) string {
+	for _, key := range MatchTags {
+		t, err := tags.Get(key)
+		if err != nil || t.Name == "" || t.Name == "-" {
+			continue
+		}
+		return t.Name
+	}
+	return ""
+}
@@ -29,6 +29,34 @@ func Test_getTag(t *testing.T) {
 			wantWriteName: "bar",
 			wantOption:    OptionTag(0),
 		},
+		{
+			name: "value options are not names",
+			args: args{
+				tag: fmt.Sprintf(templ, `,default:\"draft\"`),
+			},
+			wantName: "",
+		},
+		{
+			name: "slice options are not names",
+			args: args{
+				tag: fmt.Sprintf(templ, "Tags,join:;"),
+			},
+			wantName: "Tags",
+		},
+		{
+			name: "slice option as a name",
+			args: args{
+				tag: fmt.Sprintf(templ, "first"),
+			},
+			wantName: "first",
+		},
+		{
+			name: "matchTag is not used without MatchTags",
+			args: args{
+				tag: `json:"foo"`,
+			},
+			wantName: "",
+		},
 		{
 			name: "fix Name",
 			args: args{
@@ -58,3 +86,115 @@ func Test_getTag(t *testing.T) {
 		})
 	}
 }
+
+func Test_getTag_matchTags(t *testing.T) {
+	StructTag = "cvt"
+	MatchTags = []string{"json", "db"}
+	defer func() {
+		MatchTags = nil
+	}()
+
+	tests := []struct {
+		name     string
+		tag      string
+		wantName string
+	}{
+		{
+			name:     "json",
+			tag:      `json:"user_id,omitempty"`,
+			wantName: "user_id",
+		},
+		{
+			name:     "json is prior to db",
+			tag:      `db:"id" json:"user_id"`,
+			wantName: "user_id",
+		},
+		{
+			name:     "skip json:\"-\"",
+			tag:      `json:"-" db:"id"`,
+			wantName: "id",
+		},
+		{
+			name:     "cvt is prior to json",
+			tag:      `json:"user_id" cvt:"ID"`,
+			wantName: "ID",
+		},
+		{
+			name:     "cvt without name",
+			tag:      `json:"user_id" cvt:",first"`,
+			wantName: "user_id",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotName, _, _, _ := getTag(tt.tag)
+			if gotName != tt.wantName {
+				t.Errorf("getTag() gotName = %v, want %v", gotName, tt.wantName)
+			}
+		})
+	}
+}
+
+func Test_getValueTag(t *testing.T) {
+	StructTag = "cvt"
+	tests := []struct {
+		name       string
+		tag        string
+		wantOption ValueOption
+		wantValue  string
+	}{
+		{
+			name:       "default",
+			tag:        `cvt:",default:\"draft\""`,
+			wantOption: Default,
+			wantValue:  `"draft"`,
+		},
+		{
+			name:       "const with name",
+			tag:        `cvt:"Version, const:1"`,
+			wantOption: Const,
+			wantValue:  "1",
+		},
+		{
+			name:       "ifzero",
+			tag:        `cvt:",ifzero:StatusDraft"`,
+			wantOption: DefaultIfZero,
+			wantValue:  "StatusDraft",
+		},
+		{
+			name: "none",
+			tag:  `cvt:"read:foo"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotOption, gotValue := getValueTag(tt.tag)
+			if gotOption != tt.wantOption || gotValue != tt.wantValue {
+				t.Errorf("getValueTag() = %v, %v, want %v, %v", gotOption, gotValue, tt.wantOption, tt.wantValue)
+			}
+		})
+	}
+}
+
+func Test_getSliceTag(t *testing.T) {
+	StructTag = "cvt"
+	tests := []struct {
+		name string
+		tags []string
+		want fieldSlice
+	}{
+		{name: "none", tags: []string{`cvt:"Name"`}, want: fieldSlice{}},
+		{name: "first", tags: []string{`cvt:",first"`}, want: fieldSlice{option: SliceFirst, sep: ","}},
+		{name: "join with sep", tags: []string{`cvt:",join:;"`}, want: fieldSlice{option: SliceJoin, sep: ";"}},
+		{name: "join with quoted sep", tags: []string{`cvt:",join:\" \""`}, want: fieldSlice{option: SliceJoin, sep: " "}},
+		{name: "dst is prior to src", tags: []string{`cvt:",last"`, `cvt:",first"`}, want: fieldSlice{option: SliceLast, sep: ","}},
+		{name: "src", tags: []string{``, `cvt:",wrap"`}, want: fieldSlice{option: SliceWrap, sep: ","}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getSliceTag(tt.tags...); got != tt.want {
+				t.Errorf("getSliceTag() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package analysis
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+)
+
+// Text String, MarshalText, UnmarshalText を使って、文字列と変換する
+var Text = false
+
+var (
+	byteSliceType = types.NewSlice(types.Typ[types.Byte])
+	errorType     = types.Universe.Lookup("error").Type()
+
+	// stringerType fmt.Stringer
+	stringerType = newInterface("String", nil, []types.Type{types.Typ[types.String]})
+	// textMarshalerType encoding.TextMarshaler
+	textMarshalerType = newInterface("MarshalText", nil, []types.Type{byteSliceType, errorType})
+	// textUnmarshalerType encoding.TextUnmarshaler
+	textUnmarshalerType = newInterface("UnmarshalText", []types.Type{byteSliceType}, []types.Type{errorType})
+)
+
+// newInterface メソッドを一つだけ持つ interface
+func newInterface(name string, params, results []types.Type) *types.Interface {
+	tuple := func(ts []types.Type) *types.Tuple {
+		vars := make([]*types.Var, len(ts))
+		for i, t := range ts {
+			vars[i] = types.NewVar(token.NoPos, nil, "", t)
+		}
+		return types.NewTuple(vars...)
+	}
+	sig := types.NewSignature(nil, tuple(params), tuple(results), false)
+	return types.NewInterfaceType([]*types.Func{types.NewFunc(token.NoPos, nil, name, sig)}, nil).Complete()
+}
+
+// textAndOther String, MarshalText, UnmarshalText で文字列・[]byte と変換する。
+// MarshalText, UnmarshalText が失敗した場合は、error を返す。変換する組で無ければ ok は false
+func (fm *FuncMaker) textAndOther(dst, src Type, dstSelector, srcSelector, index string) (written, ok bool) {
+	if !Text {
+		return false, false
+	}
+	dstBytes := types.Identical(dst.typ, byteSliceType)
+	srcBytes := types.Identical(src.typ, byteSliceType)
+
+	var write func()
+	switch {
+	case (basicIs(dst.typ, types.IsString) || dstBytes) && types.Implements(src.typ, textMarshalerType):
+		write = func() {
+			if dstBytes {
+				fm.writeErrCheck(fmt.Sprintf("%s, err = %s.MarshalText()", dstSelector, srcSelector))
+				return
+			}
+			v := fm.newVar()
+			fmt.Fprintf(fm.buf, "var %s []byte\n", v)
+			fm.writeErrCheck(fmt.Sprintf("%s, err = %s.MarshalText()", v, srcSelector))
+			fmt.Fprintf(fm.buf, "%s = %s\n", dstSelector, fm.convertExpr(dst.typ, types.Invalid, v))
+		}
+	case basicIs(dst.typ, types.IsString) && types.Implements(src.typ, stringerType):
+		write = func() {
+			fmt.Fprintf(fm.buf, "%s = %s\n", dstSelector, fm.convertExpr(dst.typ, types.String, srcSelector+".String()"))
+		}
+	case (basicIs(src.typ, types.IsString) || srcBytes) && types.Implements(types.NewPointer(dst.typ), textUnmarshalerType):
+		if _, ok := dst.typ.Underlying().(*types.Pointer); ok {
+			return false, false
+		}
+		write = func() {
+			arg := srcSelector
+			if !srcBytes {
+				arg = fmt.Sprintf("[]byte(%s)", srcSelector)
+			}
+			fm.writeErrCheck(fmt.Sprintf("err = %s.UnmarshalText(%s)", dstSelector, arg))
+		}
+	default:
+		return false, false
+	}
+
+	guard, ok := fm.applyGuard(src.typ, srcSelector, index)
+	id := 0
+	if ok {
+		id = fm.openGuard(guard)
+	}
+	write()
+	if ok {
+		fm.closeGuard(id)
+	}
+	fm.dstWrittenSelector[dstSelector] = struct{}{}
+	return true, true
+}
@@ -0,0 +1,231 @@
+package analysis
+
+import (
+	"fmt"
+	"go/types"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimeUnit time.Time time.Duration と整数の単位
+type TimeUnit int
+
+const (
+	// TimeNone time.Time time.Duration の変換規則を使わない
+	TimeNone TimeUnit = iota
+	// TimeUnix 秒。time.Time は Unix()
+	TimeUnix
+	// TimeUnixMilli ミリ秒。Go 1.16 でも使えるように、time.Time は UnixNano() / 1e6
+	TimeUnixMilli
+)
+
+var (
+	// Time time.Time time.Duration と整数の単位。TimeNone のときは、タグで指定したフィールドのみ変換する
+	Time = TimeNone
+	// TimeLayout time.Time と string の変換の layout
+	TimeLayout = time.RFC3339
+)
+
+var timeUnits = map[string]TimeUnit{
+	"unix":      TimeUnix,
+	"unixmilli": TimeUnixMilli,
+}
+
+// ParseTimeUnit parses the value of the -time flag.
+func ParseTimeUnit(s string) (TimeUnit, error) {
+	if s == "" || s == "none" {
+		return TimeNone, nil
+	}
+	if unit, ok := timeUnits[s]; ok {
+		return unit, nil
+	}
+	return TimeNone, fmt.Errorf("unknown time unit %q", s)
+}
+
+// fieldTime フィールドに指定された変換規則 `cvt:",unixmilli"` `cvt:",layout:2006-01-02"`
+type fieldTime struct {
+	unit   TimeUnit
+	layout string
+}
+
+func parseTimeOption(tag string) (fieldTime, bool) {
+	if strings.HasPrefix(tag, "layout:") {
+		layout := tag[len("layout:"):]
+		if s, err := strconv.Unquote(layout); err == nil {
+			layout = s
+		}
+		return fieldTime{layout: layout}, true
+	}
+	unit, ok := timeUnits[tag]
+	return fieldTime{unit: unit}, ok
+}
+
+// isTimeOption `cvt:",unix"` などのオプションか
+func isTimeOption(tag string) bool {
+	_, ok := parseTimeOption(tag)
+	return ok
+}
+
+// getTimeTag 構造体タグで指定された変換規則。tags の先に書かれたものを優先する。
+func getTimeTag(tags ...string) fieldTime {
+	var ft fieldTime
+	for _, tag := range tags {
+		cvtTag, err := parseTag(tag)
+		if err != nil {
+			continue
+		}
+		for _, option := range cvtTag.Options {
+			t, ok := parseTimeOption(strings.Trim(option, " "))
+			if !ok {
+				continue
+			}
+			if ft.unit == TimeNone {
+				ft.unit = t.unit
+			}
+			if ft.layout == "" {
+				ft.layout = t.layout
+			}
+		}
+	}
+	return ft
+}
+
+// timeRule 書き込み中のフィールドで使う単位と layout。変換規則を使わなければ ok は false
+func (fm *FuncMaker) timeRule() (unit TimeUnit, layout string, ok bool) {
+	if Time == TimeNone && fm.time == (fieldTime{}) {
+		return TimeNone, "", false
+	}
+	unit, layout = fm.time.unit, fm.time.layout
+	if unit == TimeNone {
+		unit = Time
+	}
+	if unit == TimeNone {
+		unit = TimeUnix
+	}
+	if layout == "" {
+		layout = TimeLayout
+	}
+	return unit, layout, true
+}
+
+// isTimeType time パッケージの name 型か
+func isTimeType(t types.Type, name string) bool {
+	namedT, ok := t.(*types.Named)
+	if !ok || namedT.Obj().Pkg() == nil {
+		return false
+	}
+	return namedT.Obj().Pkg().Path() == "time" && namedT.Obj().Name() == name
+}
+
+// basicIs time.Duration 以外で、underlying が info の基本型か
+func basicIs(t types.Type, info types.BasicInfo) bool {
+	if isTimeType(t, "Duration") {
+		return false
+	}
+	b, ok := t.Underlying().(*types.Basic)
+	return ok && b.Info()&info != 0
+}
+
+// timeLayouts time パッケージの定数で書く layout
+var timeLayouts = map[string]string{
+	time.RFC3339:     "time.RFC3339",
+	time.RFC3339Nano: "time.RFC3339Nano",
+	time.RFC1123:     "time.RFC1123",
+	time.Kitchen:     "time.Kitchen",
+}
+
+func layoutExpr(layout string) string {
+	if c, ok := timeLayouts[layout]; ok {
+		return c
+	}
+	return strconv.Quote(layout)
+}
+
+// convertExpr from 型の式 expr を、t 型に変換する。from が types.Invalid であれば常に変換する
+func (fm *FuncMaker) convertExpr(t types.Type, from types.BasicKind, expr string) string {
+	if types.Identical(t, types.Typ[from]) {
+		return expr
+	}
+	name, err := fm.formatPkgType(t)
+	if err != nil {
+		return expr
+	}
+	return fmt.Sprintf("%s(%s)", name, expr)
+}
+
+// timeAndOther time.Time time.Duration と、整数・小数・文字列を変換する。
+// 文字列からの変換に失敗した場合は、error を返す。変換する組で無ければ ok は false
+func (fm *FuncMaker) timeAndOther(dst, src Type, dstSelector, srcSelector, index string) (written, ok bool) {
+	unit, layout, ok := fm.timeRule()
+	if !ok {
+		return false, false
+	}
+
+	units := map[TimeUnit]string{TimeUnix: "time.Second", TimeUnixMilli: "time.Millisecond"}
+	var expr string
+	parse := false
+	switch {
+	case isTimeType(src.typ, "Time") && basicIs(dst.typ, types.IsInteger):
+		if unit == TimeUnixMilli {
+			expr = fm.convertExpr(dst.typ, types.Int64, fmt.Sprintf("%s.UnixNano() / 1e6", srcSelector))
+		} else {
+			expr = fm.convertExpr(dst.typ, types.Int64, fmt.Sprintf("%s.Unix()", srcSelector))
+		}
+	case isTimeType(src.typ, "Time") && basicIs(dst.typ, types.IsString):
+		expr = fm.convertExpr(dst.typ, types.String, fmt.Sprintf("%s.Format(%s)", srcSelector, layoutExpr(layout)))
+	case isTimeType(dst.typ, "Time") && basicIs(src.typ, types.IsInteger):
+		v := fm.toBasic(src.typ, types.Int64, srcSelector)
+		if unit == TimeUnixMilli {
+			expr = fmt.Sprintf("time.Unix(0, %s*int64(time.Millisecond))", v)
+		} else {
+			expr = fmt.Sprintf("time.Unix(%s, 0)", v)
+		}
+	case isTimeType(dst.typ, "Time") && basicIs(src.typ, types.IsString):
+		expr = fmt.Sprintf("time.Parse(%s, %s)", layoutExpr(layout), fm.toBasic(src.typ, types.String, srcSelector))
+		parse = true
+	case isTimeType(src.typ, "Duration") && basicIs(dst.typ, types.IsInteger):
+		if unit == TimeUnixMilli {
+			expr = fm.convertExpr(dst.typ, types.Int64, fmt.Sprintf("%s.Milliseconds()", srcSelector))
+		} else {
+			expr = fm.convertExpr(dst.typ, types.Invalid, fmt.Sprintf("%s / %s", srcSelector, units[unit]))
+		}
+	case isTimeType(src.typ, "Duration") && basicIs(dst.typ, types.IsFloat):
+		expr = fm.convertExpr(dst.typ, types.Float64, fmt.Sprintf("%s.Seconds()", srcSelector))
+	case isTimeType(src.typ, "Duration") && basicIs(dst.typ, types.IsString):
+		expr = fm.convertExpr(dst.typ, types.String, fmt.Sprintf("%s.String()", srcSelector))
+	case isTimeType(dst.typ, "Duration") && basicIs(src.typ, types.IsInteger):
+		expr = fmt.Sprintf("time.Duration(%s) * %s", srcSelector, units[unit])
+	case isTimeType(dst.typ, "Duration") && basicIs(src.typ, types.IsFloat):
+		expr = fmt.Sprintf("time.Duration(%s * float64(time.Second))", fm.toBasic(src.typ, types.Float64, srcSelector))
+	case isTimeType(dst.typ, "Duration") && basicIs(src.typ, types.IsString):
+		expr = fmt.Sprintf("time.ParseDuration(%s)", fm.toBasic(src.typ, types.String, srcSelector))
+		parse = true
+	default:
+		return false, false
+	}
+
+	guard, ok := fm.applyGuard(src.typ, srcSelector, index)
+	id := 0
+	if ok {
+		id = fm.openGuard(guard)
+	}
+	if parse {
+		fm.writeErrCheck(fmt.Sprintf("%s, err = %s", dstSelector, expr))
+	} else {
+		fmt.Fprintf(fm.buf, "%s = %s\n", dstSelector, expr)
+	}
+	if ok {
+		fm.closeGuard(id)
+	}
+	fm.dstWrittenSelector[dstSelector] = struct{}{}
+	return true, true
+}
+
+// toBasic t 型の式 expr を、基本型 kind にする
+func (fm *FuncMaker) toBasic(t types.Type, kind types.BasicKind, expr string) string {
+	if types.Identical(t, types.Typ[kind]) {
+		return expr
+	}
+	return fmt.Sprintf("%s(%s)", types.Typ[kind].Name(), expr)
+}
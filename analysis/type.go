@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"go/types"
+	"strings"
 )
 
 func InitType(typ types.Type, name string) Type {
@@ -16,11 +17,14 @@ func InitType(typ types.Type, name string) Type {
 type Type struct {
 	typ  types.Type
 	name string
+	// underlying される前の named type
+	orig types.Type
 }
 
 type TypeStruct struct {
 	typ  *types.Struct
 	name string
+	orig types.Type
 }
 
 type TypeSlice struct {
@@ -28,6 +32,16 @@ type TypeSlice struct {
 	name string
 }
 
+type TypeMap struct {
+	typ  *types.Map
+	name string
+}
+
+type TypeArray struct {
+	typ  *types.Array
+	name string
+}
+
 type TypePointer struct {
 	typ  *types.Pointer
 	name string
@@ -54,6 +68,9 @@ func (fm *FuncMaker) structAndOther(dstT TypeStruct, src Type, dstSelector, srcS
 		if dOption == Ignore || dOption == ReadOnly {
 			continue
 		}
+		if vOption, _ := getValueTag(dstT.typ.Tag(i)); vOption == Const {
+			continue
+		}
 
 		written := fm.makeFunc(Type{typ: dstT.typ.Field(i).Type()}, src,
 			selectorGen(dstSelector, dstT.typ.Field(i)),
@@ -62,6 +79,7 @@ func (fm *FuncMaker) structAndOther(dstT TypeStruct, src Type, dstSelector, srcS
 			history,
 		)
 		if written {
+			fm.writeValues(dstT, dstSelector)
 			return true
 		}
 	}
@@ -69,36 +87,175 @@ func (fm *FuncMaker) structAndOther(dstT TypeStruct, src Type, dstSelector, srcS
 }
 
 func (fm *FuncMaker) otherAndStruct(dst Type, srcT TypeStruct, dstSelector, srcSelector, index string, history [][2]types.Type) bool {
-	for j := 0; j < srcT.typ.NumFields(); j++ {
-		if !fm.varVisiable(srcT.typ.Field(j)) {
+	for _, sf := range fm.srcFields(srcT, srcSelector) {
+		if sf.selector == "" {
 			continue
 		}
-		// if struct tag "cvt" exists, use struct tag
-		_, _, _, sOption := getTag(srcT.typ.Tag(j))
-		if sOption == Ignore || sOption == WriteOnly {
-			continue
-		}
-
-		written := fm.makeFunc(dst, Type{typ: srcT.typ.Field(j).Type()},
+		written := fm.makeFunc(dst, Type{typ: sf.typ},
 			dstSelector,
-			selectorGen(srcSelector, srcT.typ.Field(j)),
+			sf.selector,
 			index,
 			history,
 		)
 		if written {
+			if sf.private != nil {
+				fm.addPrivateHelper(*sf.private, false)
+			}
 			return true
 		}
 	}
 	return false
 }
 
+// srcField 読み込み可能なフィールド。getter も含む。
+type srcField struct {
+	name     string
+	typ      types.Type
+	selector string
+	embedded bool
+	// 構造体タグ。getter の場合は空
+	tag string
+	// 見えないフィールド。helper で読み込めないときは selector が空
+	private *privateField
+	// フィールドを持つ型。named type で無い場合は nil
+	source *types.TypeName
+	// `cvt:"Room:ID"` で指定された、書き込む型
+	target string
+}
+
+// isTypeName `cvt:"Room:Name"` の Room が obj の型名か。db.Room のようにパッケージ名を付けても良い
+func isTypeName(obj *types.TypeName, name string) bool {
+	if obj == nil {
+		return false
+	}
+	if name == obj.Name() {
+		return true
+	}
+	return obj.Pkg() != nil && name == obj.Pkg().Name()+"."+obj.Name()
+}
+
+// from dst のタグ `cvt:"Room:Name"` で指定された型のフィールドか。指定が無ければ全て
+func (sf srcField) from(name string) bool {
+	return name == "" || isTypeName(sf.source, name)
+}
+
+// to src のタグ `cvt:"Room:ID"` で指定された型に書き込むか。指定が無ければ全て
+func (sf srcField) to(dst types.Type) bool {
+	if sf.target == "" {
+		return true
+	}
+	namedT, ok := dst.(*types.Named)
+	return ok && isTypeName(namedT.Obj(), sf.target)
+}
+
+// splitSource `cvt:"Room:Name"` を型名とフィールド名に分ける。
+func splitSource(name string) (source, field string) {
+	i := strings.Index(name, ":")
+	if i < 0 {
+		return "", name
+	}
+	return name[:i], name[i+1:]
+}
+
+// srcFields 読み込み可能なフィールドを、優先する順に返す。
+func (fm *FuncMaker) srcFields(srcT TypeStruct, srcSelector string) []srcField {
+	fields := make([]srcField, 0, srcT.typ.NumFields())
+	owner, hasOwner := fm.privateOwner(srcT.orig)
+	for j := 0; j < srcT.typ.NumFields(); j++ {
+		var private *privateField
+		if !fm.varVisiable(srcT.typ.Field(j)) {
+			if !hasOwner || Private == PrivateSkip {
+				continue
+			}
+			private = &privateField{owner: owner, field: srcT.typ.Field(j)}
+		}
+		// if struct tag "cvt" exists, use struct tag
+		sField, sReadField, _, sOption := getTag(srcT.typ.Tag(j))
+		if sReadField != "" {
+			sField = sReadField
+		}
+		if sField == "" {
+			sField = srcT.typ.Field(j).Name()
+		}
+		if sOption == Ignore || sOption == WriteOnly {
+			continue
+		}
+		target, sField := splitSource(sField)
+
+		selector := selectorGen(srcSelector, srcT.typ.Field(j))
+		if private != nil {
+			selector = ""
+			if fm.splittable(*private) {
+				selector = fm.privateGetter(*private, srcSelector)
+			}
+		}
+		fields = append(fields, srcField{
+			name:     sField,
+			typ:      srcT.typ.Field(j).Type(),
+			selector: selector,
+			embedded: srcT.typ.Field(j).Embedded(),
+			tag:      srcT.typ.Tag(j),
+			private:  private,
+			target:   target,
+		})
+	}
+
+	getters := fm.getters(srcT.orig, srcSelector)
+	if Getter == GetterPrefer {
+		fields = append(getters, fields...)
+	} else {
+		fields = append(fields, getters...)
+	}
+	if namedT, ok := srcT.orig.(*types.Named); ok {
+		for i := range fields {
+			fields[i].source = namedT.Obj()
+		}
+	}
+	return fields
+}
+
+// targetFirst 書き込む型が指定されたフィールドを優先する。
+func targetFirst(sFields []srcField) []srcField {
+	fields := make([]srcField, 0, len(sFields))
+	for _, sf := range sFields {
+		if sf.target != "" {
+			fields = append(fields, sf)
+		}
+	}
+	if len(fields) == 0 {
+		return sFields
+	}
+	for _, sf := range sFields {
+		if sf.target == "" {
+			fields = append(fields, sf)
+		}
+	}
+	return fields
+}
+
+// structSource 変換元の struct と selector
+type structSource struct {
+	typ      TypeStruct
+	selector string
+}
+
 func (fm *FuncMaker) structAndStruct(dstT, srcT TypeStruct, dstSelector, srcSelector, index string, history [][2]types.Type) bool {
+	sources := []structSource{{typ: srcT, selector: srcSelector}}
+	return fm.structAndSources(dstT, sources, fm.srcFields(srcT, srcSelector), dstSelector, index, history)
+}
+
+// structAndSources sFields から dst のフィールドに書き込む。
+// 同じ dst のフィールドには、書き込む型が指定されたもの、sFields の先にあるものを書き込む。
+func (fm *FuncMaker) structAndSources(dstT TypeStruct, sources []structSource, sFields []srcField, dstSelector, index string, history [][2]types.Type) bool {
 	written := false
+	sFields = targetFirst(sFields)
 
 	// field 同士の比較
 
 	for i := 0; i < dstT.typ.NumFields(); i++ {
 		if !fm.varVisiable(dstT.typ.Field(i)) {
+			// PrivateSplit のときは setter として扱う
+			fm.checkPrivateDst(dstT, i, sFields)
 			continue
 		}
 		// if struct tag "cvt" exists, use struct tag
@@ -112,40 +269,73 @@ func (fm *FuncMaker) structAndStruct(dstT, srcT TypeStruct, dstSelector, srcSele
 		if dOption == Ignore || dOption == ReadOnly {
 			continue
 		}
+		if vOption, _ := getValueTag(dstT.typ.Tag(i)); vOption == Const {
+			continue
+		}
+		source, dField := splitSource(dField)
 
 		if dstT.typ.Field(i).Embedded() {
-			written = fm.makeFunc(Type{typ: dstT.typ.Field(i).Type()}, Type{typ: srcT.typ, name: srcT.name},
-				selectorGen(dstSelector, dstT.typ.Field(i)),
-				srcSelector,
-				index,
-				history,
-			) || written
+			for _, src := range sources {
+				written = fm.makeFunc(Type{typ: dstT.typ.Field(i).Type()}, Type{typ: src.typ.typ, name: src.typ.name, orig: src.typ.orig},
+					selectorGen(dstSelector, dstT.typ.Field(i)),
+					src.selector,
+					index,
+					history,
+				) || written
+			}
 			continue
 		}
-		for j := 0; j < srcT.typ.NumFields(); j++ {
-			if !fm.varVisiable(srcT.typ.Field(j)) {
-				continue
-			}
-			// if struct tag "cvt" exists, use struct tag
-			sField, sReadField, _, sOption := getTag(srcT.typ.Tag(j))
-			if sReadField != "" {
-				sField = sReadField
+		// 完全一致を優先する
+		for _, exact := range []bool{true, false} {
+			if !exact && !fuzzyMatch() {
+				break
 			}
-			if sField == "" {
-				sField = srcT.typ.Field(j).Name()
-			}
-			if sOption == Ignore || sOption == WriteOnly {
-				continue
+			for _, sf := range sFields {
+				if sf.embedded || !sf.from(source) || !sf.to(dstT.orig) {
+					continue
+				}
+
+				if !matchName(dField, sf.name, exact) {
+					continue
+				}
+				if sf.selector == "" {
+					fm.recordPrivate(*sf.private)
+					continue
+				}
+				// スライスとの変換方法、time.Time などの変換規則は、dst のタグを優先する
+				slice, tm := fm.slice, fm.time
+				fm.slice = getSliceTag(dstT.typ.Tag(i), sf.tag)
+				fm.time = getTimeTag(dstT.typ.Tag(i), sf.tag)
+				w := fm.makeFunc(Type{typ: dstT.typ.Field(i).Type()}, Type{typ: sf.typ},
+					selectorGen(dstSelector, dstT.typ.Field(i)),
+					sf.selector,
+					index,
+					history,
+				)
+				fm.slice, fm.time = slice, tm
+				if w && sf.private != nil {
+					fm.addPrivateHelper(*sf.private, false)
+				}
+				if w {
+					fm.feed(dstSelector, dstT.typ.Field(i).Name(), sf)
+				}
+				written = w || written
 			}
+		}
+	}
 
+	for _, src := range sources {
+		srcT := src.typ
+		for j := 0; j < srcT.typ.NumFields(); j++ {
 			if srcT.typ.Field(j).Embedded() {
-				continue
-			}
-
-			if dField == sField {
-				written = fm.makeFunc(Type{typ: dstT.typ.Field(i).Type()}, Type{typ: srcT.typ.Field(j).Type()},
-					selectorGen(dstSelector, dstT.typ.Field(i)),
-					selectorGen(srcSelector, srcT.typ.Field(j)),
+				_, _, _, sOption := getTag(srcT.typ.Tag(j))
+				if sOption == Ignore || sOption == WriteOnly {
+					continue
+				}
+
+				written = fm.makeFunc(Type{typ: dstT.typ, name: dstT.name, orig: dstT.orig}, Type{typ: srcT.typ.Field(j).Type()},
+					dstSelector,
+					selectorGen(src.selector, srcT.typ.Field(j)),
 					index,
 					history,
 				) || written
@@ -153,22 +343,33 @@ func (fm *FuncMaker) structAndStruct(dstT, srcT TypeStruct, dstSelector, srcSele
 		}
 	}
 
-	for j := 0; j < srcT.typ.NumFields(); j++ {
-		if srcT.typ.Field(j).Embedded() {
-			_, _, _, sOption := getTag(srcT.typ.Tag(j))
-			if sOption == Ignore || sOption == WriteOnly {
-				continue
+	// 見えるフィールドが無いときは、setter で書き込む
+	// PrivateSplit のときは、見えないフィールドも helper で書き込む
+	for _, st := range fm.setters(dstT) {
+		for _, exact := range []bool{true, false} {
+			if !exact && !fuzzyMatch() {
+				break
+			}
+			for _, sf := range sFields {
+				if sf.embedded || sf.selector == "" || !sf.to(dstT.orig) || !matchName(st.name, sf.name, exact) {
+					continue
+				}
+				w := fm.setterAndOther(st, Type{typ: sf.typ}, dstSelector, sf.selector, index, history)
+				if w && sf.private != nil {
+					fm.addPrivateHelper(*sf.private, false)
+				}
+				if w {
+					fm.feed(dstSelector, st.name, sf)
+				}
+				written = w || written
 			}
-
-			written = fm.makeFunc(Type{typ: dstT.typ, name: dstT.name}, Type{typ: srcT.typ.Field(j).Type()},
-				dstSelector,
-				selectorGen(srcSelector, srcT.typ.Field(j)),
-				index,
-				history,
-			) || written
 		}
 	}
 
+	if written {
+		fm.writeValues(dstT, dstSelector)
+	}
+
 	// 構造体自体とフィールドの比較
 
 	// TODO
@@ -201,27 +402,8 @@ func (fm *FuncMaker) structAndStruct(dstT, srcT TypeStruct, dstSelector, srcSele
 	return written
 }
 
-func (fm *FuncMaker) sliceAndOther(dstT TypeSlice, src Type, dstSelector, srcSelector, index string, history [][2]types.Type) bool {
-	return fm.deferWrite(func(tmpFm *FuncMaker) bool {
-		dt, err := tmpFm.formatPkgType(dstT.typ)
-		if err != nil {
-			return false
-		}
-		fmt.Fprintf(tmpFm.buf, "%s = make(%s, 1)\n", dstSelector, dt)
-		return tmpFm.makeFunc(Type{typ: dstT.typ.Elem()}, src, dstSelector+"[0]", srcSelector, index, history)
-	})
-}
-
-func (fm *FuncMaker) otherAndSlice(dst Type, srcT TypeSlice, dstSelector, srcSelector, index string, history [][2]types.Type) bool {
-	return fm.deferWrite(func(tmpFm *FuncMaker) bool {
-		fmt.Fprintf(tmpFm.buf, "if len(%s)>0 {\n", srcSelector)
-		written := tmpFm.makeFunc(dst, Type{typ: srcT.typ.Elem()}, dstSelector, srcSelector+"[0]", index, history)
-		fmt.Fprintln(tmpFm.buf, "}")
-		return written
-	})
-}
-
 func (fm *FuncMaker) sliceAndSlice(dstT, srcT TypeSlice, dstSelector, srcSelector, index string, history [][2]types.Type) bool {
+	prevIndex := index
 	index = nextIndex(index)
 
 	return fm.deferWrite(func(tmpFm *FuncMaker) bool {
@@ -230,15 +412,25 @@ func (fm *FuncMaker) sliceAndSlice(dstT, srcT TypeSlice, dstSelector, srcSelecto
 			return false
 		}
 
-		fmt.Fprintf(tmpFm.buf, "%s = make(%s, len(%s))\n", dstSelector, dt, srcSelector)
-		fmt.Fprintf(tmpFm.buf, "for %s := range %s {\n", index, srcSelector)
-		written := tmpFm.makeFunc(Type{typ: dstT.typ.Elem()}, Type{typ: srcT.typ.Elem()},
-			dstSelector+"["+index+"]",
-			srcSelector+"["+index+"]",
-			index,
-			history,
-		)
-		fmt.Fprintf(tmpFm.buf, "}\n")
+		written := tmpFm.writeCollection(srcT.typ, dstSelector, srcSelector, prevIndex, func() bool {
+			if useCvtUtil() && tmpFm.sliceCvtUtil(dstT, srcT, dstSelector, srcSelector, history) {
+				return true
+			}
+			if Mode == ModeInto {
+				tmpFm.reuseSlice(dt, dstSelector, srcSelector)
+			} else {
+				fmt.Fprintf(tmpFm.buf, "%s = make(%s, len(%s))\n", dstSelector, dt, srcSelector)
+			}
+			fmt.Fprintf(tmpFm.buf, "for %s := range %s {\n", index, srcSelector)
+			written := tmpFm.makeFunc(Type{typ: dstT.typ.Elem()}, Type{typ: srcT.typ.Elem()},
+				dstSelector+"["+index+"]",
+				srcSelector+"["+index+"]",
+				index,
+				history,
+			)
+			fmt.Fprintf(tmpFm.buf, "}\n")
+			return written
+		})
 		if written {
 			tmpFm.dstWrittenSelector[dstSelector] = struct{}{}
 		}
@@ -246,9 +438,19 @@ func (fm *FuncMaker) sliceAndSlice(dstT, srcT TypeSlice, dstSelector, srcSelecto
 	})
 }
 
+// reuseSlice into mode では、要素の対応しないフィールドを残すため、dst の slice を使い回す。
+// 足りない分だけ確保し直し、既存の要素はコピーする。
+func (fm *FuncMaker) reuseSlice(dt, dstSelector, srcSelector string) {
+	v := fm.newVar()
+	fmt.Fprintf(fm.buf, "if len(%s) >= len(%s) {\n%s = %s[:len(%s)]\n} else {\n",
+		dstSelector, srcSelector, dstSelector, dstSelector, srcSelector)
+	fmt.Fprintf(fm.buf, "%s := make(%s, len(%s))\ncopy(%s, %s)\n%s = %s\n}\n",
+		v, dt, srcSelector, v, dstSelector, dstSelector, v)
+}
+
 func (fm *FuncMaker) named(namedT TypeNamed, selector string) (Type, string) {
 	namedT.typ.Obj().Pkg()
-	return Type{typ: namedT.typ.Underlying(), name: namedT.typ.String()}, selector
+	return Type{typ: namedT.typ.Underlying(), name: namedT.typ.String(), orig: namedT.typ}, selector
 }
 
 func (fm *FuncMaker) namedAndOther(dstT TypeNamed, src Type, dstSelector, srcSelector, index string, history [][2]types.Type) bool {
@@ -262,32 +464,58 @@ func (fm *FuncMaker) otherAndNamed(dst Type, srcT TypeNamed, dstSelector, srcSel
 }
 
 func (fm *FuncMaker) namedAndNamed(dstT, srcT TypeNamed, dstSelector, srcSelector, index string, history [][2]types.Type) bool {
-	funcName, err := fm.getFuncName(dstT.typ, srcT.typ)
+	conv, err := fm.getConverter(dstT.typ, srcT.typ)
 	if err != nil {
 		return false
 	}
+	funcName := conv.key()
 	if !fm.isAlreadyExist(funcName) {
-		newFM := &FuncMaker{
-			buf:                new(bytes.Buffer),
-			pkg:                fm.pkg,
-			parentFunc:         fm,
-			dstWrittenSelector: map[string]struct{}{},
-		}
-		tmp := make([]*FuncMaker, 0, 10)
-		newFM.childFunc = &tmp
-
-		*fm.childFunc = append(*fm.childFunc, newFM)
+		newFM := fm.newChild()
 		newFM.MakeFunc(Type{typ: dstT.typ, name: dstT.name}, Type{typ: srcT.typ, name: srcT.name})
 	}
-	if funcName == fm.funcName {
-		return fm.makeFunc(Type{typ: dstT.typ.Underlying(), name: dstT.typ.String()}, Type{typ: srcT.typ.Underlying(), name: srcT.typ.String()}, dstSelector, srcSelector, index, history)
+	// 関数の中身。再帰する型の内側では、自身を呼び出す
+	if funcName == fm.funcName && dstSelector == fm.rootDst {
+		if fm.enumAndEnum(dstT, srcT, dstSelector, srcSelector) {
+			return true
+		}
+		return fm.makeFunc(Type{typ: dstT.typ.Underlying(), name: dstT.typ.String(), orig: dstT.typ}, Type{typ: srcT.typ.Underlying(), name: srcT.typ.String(), orig: srcT.typ}, dstSelector, srcSelector, index, history)
 	}
 
-	fmt.Fprintf(fm.buf, "%s = %s(%s)\n", dstSelector, funcName, srcSelector)
+	if conv.into && !addressable(srcSelector) {
+		// 関数呼び出しの結果は、一時変数に入れてから渡す
+		v := fm.newVar()
+		fmt.Fprintf(fm.buf, "{\n%s := %s\n%s\n}\n", v, srcSelector, fm.call(conv, dstSelector, v))
+	} else {
+		fmt.Fprintf(fm.buf, "%s\n", fm.call(conv, dstSelector, srcSelector))
+	}
 	fm.dstWrittenSelector[dstSelector] = struct{}{}
 	return true
 }
 
+// newChild 新たに関数を作る FuncMaker を追加する。
+func (fm *FuncMaker) newChild() *FuncMaker {
+	newFM := &FuncMaker{
+		buf:                new(bytes.Buffer),
+		pkg:                fm.pkg,
+		parentFunc:         fm,
+		dstWrittenSelector: map[string]struct{}{},
+		errs:               fm.errs,
+		tmpVars:            new(int),
+		helpers:            fm.helpers,
+		privates:           fm.privates,
+		names:              fm.names,
+		imports:            fm.imports,
+		fallible:           new(bool),
+		recursive:          new(bool),
+		visited:            new(bool),
+	}
+	tmp := make([]*FuncMaker, 0, 10)
+	newFM.childFunc = &tmp
+
+	*fm.childFunc = append(*fm.childFunc, newFM)
+	return newFM
+}
+
 // TODO fix pointer
 
 func (fm *FuncMaker) pointer(pointerT TypePointer, selector string) (Type, string) {
@@ -296,32 +524,102 @@ func (fm *FuncMaker) pointer(pointerT TypePointer, selector string) (Type, strin
 
 func (fm *FuncMaker) pointerAndOther(dstT TypePointer, src Type, dstSelector, srcSelector, index string, history [][2]types.Type) bool {
 	return fm.deferWrite(func(tmpFm *FuncMaker) bool {
+		// src が nil であれば、dst も nil のまま
+		guard, ok := fmt.Sprintf("%s != nil", srcSelector), nilable(src.typ)
+		if ok {
+			tmpFm.noGuard = true
+		} else {
+			guard, ok = tmpFm.zeroGuard(src.typ, srcSelector)
+		}
+		id := 0
+		if ok {
+			id = tmpFm.openGuard(guard)
+		}
+
+		// **T などは、全ての段を確保する
 		selector := dstSelector
-		dst, dstSelector := fm.pointer(dstT, dstSelector)
-		dt, err := tmpFm.formatPkgType(dstT.typ.Elem())
-		if err != nil {
-			return false
+		dst := Type{typ: dstT.typ}
+		allocs := make([]pointerAlloc, 0, 1)
+		for {
+			pt, ok := dst.typ.Underlying().(*types.Pointer)
+			if !ok {
+				break
+			}
+			dt, err := tmpFm.formatPkgType(pt.Elem())
+			if err != nil {
+				return false
+			}
+			allocs = append(allocs, pointerAlloc{selector: dstSelector, typeName: dt})
+			dst, dstSelector = Type{typ: pt.Elem()}, fmt.Sprintf("(*%s)", dstSelector)
 		}
-		fmt.Fprintf(tmpFm.buf, "%s = new(%s)\n", selector, dt)
-		return tmpFm.makeFunc(dst, src, dstSelector, srcSelector, index, history)
+
+		// 書き込む文が決まってから確保する
+		buf, lazy := tmpFm.buf, tmpFm.lazy
+		tmpFm.buf, tmpFm.lazy = new(bytes.Buffer), &lazyGuards{allocs: allocs}
+		written := tmpFm.makeFunc(dst, src, dstSelector, srcSelector, index, history)
+		fmt.Fprint(buf, lazyAlloc(tmpFm.buf.String(), tmpFm.lazy))
+		tmpFm.buf, tmpFm.lazy = buf, lazy
+
+		if ok {
+			tmpFm.closeNilGuard(id, selector)
+		}
+		return written
 	})
 }
 
+// nilable nil になる型か
+func nilable(t types.Type) bool {
+	switch t.Underlying().(type) {
+	case *types.Pointer, *types.Slice, *types.Map, *types.Interface, *types.Signature, *types.Chan:
+		return true
+	}
+	return false
+}
+
+// closeNilGuard if src != nil { を閉じる。ModeInto では、src が nil であれば dst も nil にする
+func (fm *FuncMaker) closeNilGuard(id int, dstSelector string) {
+	if Mode == ModeInto {
+		fm.closeGuardElse(id, fmt.Sprintf("%s = nil\n", dstSelector))
+	} else {
+		fm.closeGuard(id)
+	}
+}
+
 func (fm *FuncMaker) otherAndPointer(dst Type, srcT TypePointer, dstSelector, srcSelector, index string, history [][2]types.Type) bool {
 	return fm.deferWrite(func(tmpFm *FuncMaker) bool {
-		fmt.Fprintf(tmpFm.buf, "if %s != nil {\n", srcSelector)
+		id := tmpFm.openGuard(srcSelector + " != nil")
 
+		// nil で無ければ、ゼロ値でも書き込む
+		tmpFm.noGuard = true
 		src, srcSelector := fm.pointer(srcT, srcSelector)
 		written := tmpFm.makeFunc(dst, src, dstSelector, srcSelector, index, history)
 
-		fmt.Fprintf(tmpFm.buf, "}\n")
+		tmpFm.closeGuard(id)
 		return written
 	})
 }
 
 func (fm *FuncMaker) pointerAndPointer(dstT, srcT TypePointer, dstSelector, srcSelector, index string, history [][2]types.Type) bool {
+	// dst より深い src の段は、全て nil でないときだけ変換する。**T -> *T など
+	guards := []string{srcSelector}
+	src, elemSelector := fm.pointer(srcT, srcSelector)
+	for {
+		if _, ok := dstT.typ.Elem().Underlying().(*types.Pointer); ok {
+			break
+		}
+		pt, ok := src.typ.Underlying().(*types.Pointer)
+		if !ok {
+			break
+		}
+		guards = append(guards, elemSelector)
+		src, elemSelector = Type{typ: pt.Elem()}, fmt.Sprintf("(*%s)", elemSelector)
+	}
+
+	if useCvtUtil() && len(guards) == 1 && fm.pointerCvtUtil(dstT, srcT, dstSelector, srcSelector, history) {
+		return true
+	}
 	return fm.deferWrite(func(tmpFm *FuncMaker) bool {
-		fmt.Fprintf(tmpFm.buf, "if %s != nil {\n", srcSelector)
+		id := tmpFm.openGuard(strings.Join(guards, " != nil && ") + " != nil")
 
 		selector := dstSelector
 		dst, dstSelector := fm.pointer(dstT, dstSelector)
@@ -329,11 +627,11 @@ func (fm *FuncMaker) pointerAndPointer(dstT, srcT TypePointer, dstSelector, srcS
 		if err != nil {
 			return false
 		}
-		fmt.Fprintf(tmpFm.buf, "%s = new(%s)\n", selector, dt)
-		src, srcSelector := fm.pointer(srcT, srcSelector)
-		written := tmpFm.makeFunc(dst, src, dstSelector, srcSelector, index, history)
+		fmt.Fprint(tmpFm.buf, allocPointer(selector, dt))
+		tmpFm.noGuard = true
+		written := tmpFm.makeFunc(dst, src, dstSelector, elemSelector, index, history)
 
-		fmt.Fprintf(tmpFm.buf, "}\n")
+		tmpFm.closeNilGuard(id, selector)
 		return written
 	})
 }
@@ -0,0 +1,131 @@
+package analysis
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// Defaults -default で指定された初期値。key は "Type.Field" または "pkg.Type.Field"
+var Defaults = map[string]string{}
+
+// lookupDefault structName の field に対する初期値を返す。
+// 複数の key が一致するときは、長い方を優先する。
+func lookupDefault(structName, field string) (value string, ok bool) {
+	matched := ""
+	for key, v := range Defaults {
+		i := strings.LastIndex(key, ".")
+		if i < 0 || key[i+1:] != field {
+			continue
+		}
+		typ := key[:i]
+		if structName != typ &&
+			!strings.HasSuffix(structName, "."+typ) &&
+			!strings.HasSuffix(structName, "/"+typ) {
+			continue
+		}
+		if len(key) > len(matched) {
+			matched, value, ok = key, v, true
+		}
+	}
+	return
+}
+
+// writeValues 構造体のフィールドに定数・初期値を代入する。
+func (fm *FuncMaker) writeValues(dstT TypeStruct, dstSelector string) bool {
+	written := false
+	for i := 0; i < dstT.typ.NumFields(); i++ {
+		field := dstT.typ.Field(i)
+		if !fm.varVisiable(field) {
+			continue
+		}
+		option, value := getValueTag(dstT.typ.Tag(i))
+		if option == 0 {
+			var ok bool
+			value, ok = lookupDefault(dstT.name, field.Name())
+			if !ok {
+				continue
+			}
+			option = Default
+		}
+		// ModeInto ModeApply の場合は、既にある値を初期値とする
+		if option == Default && inPlace() {
+			continue
+		}
+		if err := fm.checkValue(field.Type(), value); err != nil {
+			fm.addError(fmt.Errorf("%s: %w", selectorGen(dstSelector, field), err))
+			continue
+		}
+
+		selector := selectorGen(dstSelector, field)
+		switch option {
+		case Const, Default:
+			if fm.dstWritten(selector) {
+				continue
+			}
+			fmt.Fprintf(fm.buf, "%s = %s\n", selector, value)
+		case DefaultIfZero:
+			if !fm.dstWritten(selector) {
+				fmt.Fprintf(fm.buf, "%s = %s\n", selector, value)
+				break
+			}
+			zero, err := fm.zeroValue(field.Type())
+			if err != nil {
+				fm.addError(fmt.Errorf("%s: %w", selector, err))
+				continue
+			}
+			fmt.Fprintf(fm.buf, "if %s == %s {\n%s = %s\n}\n", selector, zero, selector, value)
+		}
+		fm.dstWrittenSelector[selector] = struct{}{}
+		written = true
+	}
+	return written
+}
+
+// checkValue value が t に代入可能な式か検査する。
+func (fm *FuncMaker) checkValue(t types.Type, value string) error {
+	tv, err := types.Eval(token.NewFileSet(), fm.pkg, token.NoPos, value)
+	if err != nil {
+		return err
+	}
+	if !types.AssignableTo(tv.Type, t) {
+		return fmt.Errorf("cannot use %s (%s) as %s value", value, tv.Type, t)
+	}
+
+	// 定数は表現可能か見る (int8 に 300 など)
+	if b, ok := t.Underlying().(*types.Basic); ok && tv.Value != nil {
+		_, err := types.Eval(token.NewFileSet(), fm.pkg, token.NoPos,
+			fmt.Sprintf("%s(%s)", b.Name(), value))
+		if err != nil {
+			return fmt.Errorf("cannot use %s as %s value: %w", value, t, err)
+		}
+	}
+	return nil
+}
+
+// zeroValue t のゼロ値と比較するための式を返す。
+func (fm *FuncMaker) zeroValue(t types.Type) (string, error) {
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsBoolean != 0:
+			return "false", nil
+		case u.Info()&types.IsString != 0:
+			return `""`, nil
+		case u.Info()&types.IsNumeric != 0:
+			return "0", nil
+		}
+	case *types.Pointer, *types.Slice, *types.Map, *types.Chan, *types.Signature, *types.Interface:
+		return "nil", nil
+	case *types.Struct:
+		if _, ok := t.(*types.Named); ok && types.Comparable(t) {
+			typeName, err := fm.formatPkgType(t)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("(%s{})", typeName), nil
+		}
+	}
+	return "", fmt.Errorf("cannot compare %s with zero value", t)
+}
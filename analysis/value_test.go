@@ -0,0 +1,63 @@
+package analysis
+
+import (
+	"go/types"
+	"testing"
+)
+
+func Test_checkValue(t *testing.T) {
+	fm := InitFuncMaker(types.NewPackage("a", "a"))
+	tests := []struct {
+		name    string
+		typ     types.Type
+		value   string
+		wantErr bool
+	}{
+		{name: "string", typ: types.Typ[types.String], value: `"draft"`},
+		{name: "int", typ: types.Typ[types.Int], value: "1"},
+		{name: "nil pointer", typ: types.NewPointer(types.Typ[types.Int]), value: "nil"},
+		{name: "string to int", typ: types.Typ[types.Int], value: `"1"`, wantErr: true},
+		{name: "int to string", typ: types.Typ[types.String], value: "1", wantErr: true},
+		{name: "float to int", typ: types.Typ[types.Int], value: "1.5", wantErr: true},
+		{name: "overflow", typ: types.Typ[types.Int8], value: "300", wantErr: true},
+		{name: "undefined", typ: types.Typ[types.Int], value: "foo", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := fm.checkValue(tt.typ, tt.value); (err != nil) != tt.wantErr {
+				t.Errorf("checkValue() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_lookupDefault(t *testing.T) {
+	Defaults = map[string]string{
+		"Event.Status":        `"draft"`,
+		"domain.Event.Status": `"open"`,
+		"Room.Name":           `"none"`,
+	}
+	defer func() {
+		Defaults = map[string]string{}
+	}()
+
+	tests := []struct {
+		structName string
+		field      string
+		wantValue  string
+		wantOk     bool
+	}{
+		{structName: "example.com/x/domain.Event", field: "Status", wantValue: `"open"`, wantOk: true},
+		{structName: "example.com/x/db.Event", field: "Status", wantValue: `"draft"`, wantOk: true},
+		{structName: "example.com/x/db.MyRoom", field: "Name"},
+		{structName: "example.com/x/db.Event", field: "Name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.structName+"."+tt.field, func(t *testing.T) {
+			gotValue, gotOk := lookupDefault(tt.structName, tt.field)
+			if gotValue != tt.wantValue || gotOk != tt.wantOk {
+				t.Errorf("lookupDefault() = %v, %v, want %v, %v", gotValue, gotOk, tt.wantValue, tt.wantOk)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package analysis
+
+import (
+	"fmt"
+	"go/types"
+	"strings"
+)
+
+// CollectionKind 要素の変換と一緒に生成する、コレクションの変換
+type CollectionKind int
+
+const (
+	// CollectionSlice []X -> []Y
+	CollectionSlice CollectionKind = iota
+	// CollectionPointers []*X -> []*Y
+	CollectionPointers
+	// CollectionMap map[K]X -> map[K]Y
+	CollectionMap
+)
+
+// Collection 生成するコレクションの変換。Key は CollectionMap の key の型名
+type Collection struct {
+	Kind CollectionKind
+	Key  string
+}
+
+// Collections named type 同士の変換ごとに生成する、コレクションの変換
+var Collections []Collection
+
+// ParseCollection parses a value of the -collections flag: slice, pointers or map:K.
+func ParseCollection(s string) (Collection, error) {
+	switch s {
+	case "slice":
+		return Collection{Kind: CollectionSlice}, nil
+	case "pointers":
+		return Collection{Kind: CollectionPointers}, nil
+	}
+	if strings.HasPrefix(s, "map:") && len(s) > len("map:") {
+		return Collection{Kind: CollectionMap, Key: s[len("map:"):]}, nil
+	}
+	return Collection{}, fmt.Errorf("unknown collection %q: want slice, pointers or map:K", s)
+}
+
+// wrap 要素の型 t のコレクション
+func (fm *FuncMaker) wrap(c Collection, t types.Type) (types.Type, error) {
+	switch c.Kind {
+	case CollectionPointers:
+		return types.NewSlice(types.NewPointer(t)), nil
+	case CollectionMap:
+		key, err := fm.lookupType(c.Key)
+		if err != nil {
+			return nil, err
+		}
+		if !types.Comparable(key) {
+			return nil, fmt.Errorf("map key %s is not comparable", c.Key)
+		}
+		return types.NewMap(key, t), nil
+	}
+	return types.NewSlice(t), nil
+}
+
+// lookupType 出力するパッケージから見た型名の型。string, ID, uuid.UUID
+func (fm *FuncMaker) lookupType(name string) (types.Type, error) {
+	if obj, ok := types.Universe.Lookup(name).(*types.TypeName); ok {
+		return obj.Type(), nil
+	}
+	scope, typeName := fm.pkg.Scope(), name
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		scope = nil
+		for _, pkg := range fm.pkg.Imports() {
+			if pkg.Name() == name[:i] {
+				scope, typeName = pkg.Scope(), name[i+1:]
+				break
+			}
+		}
+	}
+	if scope != nil {
+		if obj, ok := scope.Lookup(typeName).(*types.TypeName); ok {
+			return obj.Type(), nil
+		}
+	}
+	return nil, fmt.Errorf("unknown type %q", name)
+}
+
+// makeCollections 要素の変換を呼び出す、コレクションの変換を生成する。
+func (fm *FuncMaker) makeCollections(dstType, srcType types.Type) {
+	for _, c := range Collections {
+		dst, err := fm.wrap(c, dstType)
+		if err != nil {
+			fm.addError(err)
+			return
+		}
+		src, _ := fm.wrap(c, srcType)
+		conv, err := fm.getConverter(dst, src)
+		if err != nil || fm.isAlreadyExist(conv.key()) {
+			continue
+		}
+		newFM := fm.newChild()
+		newFM.collection = true
+		newFM.MakeFunc(Type{typ: dst}, Type{typ: src})
+	}
+}
@@ -0,0 +1,81 @@
+package cvtutil
+
+import "testing"
+
+// 生成される関数と同じ形で、ループと cvtutil を比べる
+
+type event struct {
+	ID    int
+	Tags  []string
+	Rooms []room
+	Owner *room
+}
+
+type room struct {
+	Name     string
+	Capacity int
+}
+
+type eventDST struct {
+	ID    int64
+	Tags  []string
+	Rooms []roomDST
+	Owner *roomDST
+}
+
+type roomDST struct {
+	Name     string
+	Capacity int64
+}
+
+func convRoomToRoomDST(src room) (dst roomDST) {
+	dst.Name = src.Name
+	dst.Capacity = int64(src.Capacity)
+	return
+}
+
+func convEventToEventDSTLoop(src event) (dst eventDST) {
+	dst.ID = int64(src.ID)
+	dst.Tags = src.Tags
+	dst.Rooms = make([]roomDST, len(src.Rooms))
+	for i := range src.Rooms {
+		dst.Rooms[i] = convRoomToRoomDST(src.Rooms[i])
+	}
+	if src.Owner != nil {
+		dst.Owner = new(roomDST)
+		(*dst.Owner) = convRoomToRoomDST((*src.Owner))
+	}
+	return
+}
+
+func convEventToEventDSTCvtutil(src event) (dst eventDST) {
+	dst.ID = int64(src.ID)
+	dst.Tags = src.Tags
+	dst.Rooms = MapSlice(src.Rooms, convRoomToRoomDST)
+	dst.Owner = MapPtr(src.Owner, convRoomToRoomDST)
+	return
+}
+
+func benchEvent() event {
+	e := event{ID: 1, Tags: []string{"a", "b"}, Owner: &room{Name: "owner", Capacity: 1}}
+	for i := 0; i < 100; i++ {
+		e.Rooms = append(e.Rooms, room{Name: "room", Capacity: i})
+	}
+	return e
+}
+
+func BenchmarkLoop(b *testing.B) {
+	e := benchEvent()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		convEventToEventDSTLoop(e)
+	}
+}
+
+func BenchmarkCvtutil(b *testing.B) {
+	e := benchEvent()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		convEventToEventDSTCvtutil(e)
+	}
+}
@@ -0,0 +1,45 @@
+// Package cvtutil は、gotypeconverter -cvtutil で生成した関数から呼び出す、
+// スライス・マップ・ポインタを変換する関数です。
+//
+// Go 1.18 以降が必要なので、gotypeconverter とは別のモジュールです。
+// リリースは、cvtutil/v0.1.0 のように cvtutil/ を付けたタグで行います。
+package cvtutil
+
+// MapSlice src の要素を f で変換したスライスを返します。
+// src が nil の場合も、長さ 0 のスライスを返します。
+func MapSlice[S, D any](src []S, f func(S) D) []D {
+	dst := make([]D, len(src))
+	for i := range src {
+		dst[i] = f(src[i])
+	}
+	return dst
+}
+
+// MapMap src の要素を f で変換したマップを返します。key はそのままです。
+// src が nil の場合も、空のマップを返します。
+func MapMap[K comparable, S, D any](src map[K]S, f func(S) D) map[K]D {
+	dst := make(map[K]D, len(src))
+	for k, v := range src {
+		dst[k] = f(v)
+	}
+	return dst
+}
+
+// MapPtr src が指す値を f で変換して、そのポインタを返します。
+// src が nil の場合は nil を返します。
+func MapPtr[S, D any](src *S, f func(S) D) *D {
+	if src == nil {
+		return nil
+	}
+	dst := f(*src)
+	return &dst
+}
+
+// FirstOrZero src の最初の要素を返します。src が空の場合はゼロ値を返します。
+func FirstOrZero[T any](src []T) T {
+	if len(src) == 0 {
+		var zero T
+		return zero
+	}
+	return src[0]
+}
@@ -0,0 +1,44 @@
+package cvtutil
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestMapSlice(t *testing.T) {
+	if got := MapSlice([]int{1, 2}, strconv.Itoa); !reflect.DeepEqual(got, []string{"1", "2"}) {
+		t.Errorf("MapSlice() = %v", got)
+	}
+	if got := MapSlice([]int(nil), strconv.Itoa); got == nil || len(got) != 0 {
+		t.Errorf("MapSlice(nil) = %#v, want empty slice", got)
+	}
+}
+
+func TestMapMap(t *testing.T) {
+	if got := MapMap(map[string]int{"a": 1}, strconv.Itoa); !reflect.DeepEqual(got, map[string]string{"a": "1"}) {
+		t.Errorf("MapMap() = %v", got)
+	}
+	if got := MapMap(map[string]int(nil), strconv.Itoa); got == nil || len(got) != 0 {
+		t.Errorf("MapMap(nil) = %#v, want empty map", got)
+	}
+}
+
+func TestMapPtr(t *testing.T) {
+	n := 1
+	if got := MapPtr(&n, strconv.Itoa); got == nil || *got != "1" {
+		t.Errorf("MapPtr() = %v", got)
+	}
+	if got := MapPtr((*int)(nil), strconv.Itoa); got != nil {
+		t.Errorf("MapPtr(nil) = %v, want nil", got)
+	}
+}
+
+func TestFirstOrZero(t *testing.T) {
+	if got := FirstOrZero([]string{"a", "b"}); got != "a" {
+		t.Errorf("FirstOrZero() = %v", got)
+	}
+	if got := FirstOrZero([]string(nil)); got != "" {
+		t.Errorf("FirstOrZero(nil) = %v", got)
+	}
+}
@@ -0,0 +1,3 @@
+module github.com/fuji8/gotypeconverter/cvtutil
+
+go 1.18
@@ -2,12 +2,18 @@ package gotypeconverter
 
 import (
 	"errors"
+	"flag"
 	"fmt"
 	"go/ast"
+	"go/build"
+	"go/types"
 	"io/ioutil"
 	"math/rand"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"sync/atomic"
 	"time"
 
@@ -26,7 +32,20 @@ var (
 	flagOutput  string
 	flagVersion bool
 
-	flagSrc, flagDst, flagPkg, flagStructTag string
+	flagSetter, flagConstructor, flagGraph, flagDeepCopy, flagCvtUtil, flagNilIfZero, flagText bool
+
+	flagSrc, flagDst, flagType, flagPkg, flagStructTag string
+
+	flagMatch, flagEnumUnknown, flagGetter, flagPrivate string
+	flagStyle, flagMethodTo, flagMethodFrom             string
+	flagFuncName, flagVisibility, flagCollision         string
+	flagMode, flagNil                                   string
+	flagSliceToScalar, flagScalarToSlice, flagJoinSep   string
+	flagTime, flagTimeLayout                            string
+	flagMatchTag, flagStripPrefix, flagStripSuffix      stringsFlag
+	flagCollections                                     stringsFlag
+	flagDefault                                         defaultsFlag
+	flagEnum, flagPairStyle                             renamesFlag
 
 	tmpFilePath    string
 	uniqueFuncName string
@@ -36,15 +55,244 @@ var (
 
 func init() {
 	Generator.Flags.StringVar(&flagOutput, "o", "", "output file; if nil, output stdout")
-	Generator.Flags.StringVar(&flagSrc, "s", "", "source type")
-	Generator.Flags.StringVar(&flagDst, "d", "", "destination type")
+	Generator.Flags.StringVar(&flagSrc, "s", "", "source type; comma separated types (e.g. Event,Room) are merged into the destination")
+	Generator.Flags.StringVar(&flagDst, "d", "", "destination type; comma separated types (e.g. domain.Event,domain.Room) are returned as multiple results")
+	Generator.Flags.StringVar(&flagType, "t", "", "type to deep copy; same as -s T -d T -deepcopy -nil preserve")
 	Generator.Flags.BoolVar(&flagVersion, "v", false, "version")
 	Generator.Flags.StringVar(&flagPkg, "pkg", "", "output package; if nil, the directoryName and packageName must be same and will be used")
 	Generator.Flags.StringVar(&flagStructTag, "structTag", "cvt", "")
+	Generator.Flags.StringVar(&flagMatch, "match", "exact", "field name matching: exact, ignorecase or normalize")
+	Generator.Flags.Var(&flagMatchTag, "matchTag", "struct tags used as the field name when there is no structTag (e.g. json,db); comma separated, first one wins")
+	Generator.Flags.Var(&flagStripPrefix, "strip-prefix", "prefix removed from field names before matching; comma separated")
+	Generator.Flags.Var(&flagStripSuffix, "strip-suffix", "suffix removed from field names before matching; comma separated")
+	Generator.Flags.Var(&flagDefault, "default", "default value of a destination field, Type.Field=value (e.g. Event.Status=\"draft\"); can be repeated")
+	Generator.Flags.Var(&flagEnum, "enum", "constant name mapping of enum types, SrcConst=DstConst; comma separated")
+	Generator.Flags.StringVar(&flagGetter, "getter", "none", "use zero-argument methods of the source (GetX, X) as fields: none, fallback or prefer")
+	Generator.Flags.BoolVar(&flagSetter, "setter", true, "write through SetX(v) methods when the destination has no visible field X")
+	Generator.Flags.BoolVar(&flagConstructor, "constructor", false, "create the destination with New<Type>, matching parameter names to source fields")
+	Generator.Flags.StringVar(&flagPrivate, "private", "skip", "unexported fields of other packages: skip, error or split (generate accessors into the owning packages)")
+	Generator.Flags.StringVar(&flagStyle, "style", "function", "generate converters as function or method (on the source or destination type declared in the output package)")
+	Generator.Flags.Var(&flagPairStyle, "pairStyle", "style of a type pair, Src:Dst=function|method (e.g. Event:domain.Event=method); comma separated")
+	Generator.Flags.StringVar(&flagMethodTo, "methodTo", "To{{.Dst.Name}}", "template of the method name on the source type")
+	Generator.Flags.StringVar(&flagMethodFrom, "methodFrom", "From{{.Src.Name}}", "template of the method name on the destination type")
+	Generator.Flags.StringVar(&flagFuncName, "funcName", "", "template of the function name (e.g. {{.Src.Name}}To{{title .Dst.Pkg}}{{.Dst.Name}}); if nil, Conv${src}To${dst}")
+	Generator.Flags.StringVar(&flagVisibility, "visibility", "keep", "case of the first letter of generated names: keep, exported or unexported")
+	Generator.Flags.StringVar(&flagCollision, "collision", "suffix", "when different type pairs get the same name: suffix (append 2, 3, ...) or error")
+	Generator.Flags.StringVar(&flagMode, "mode", "return", "signature of converters: return (func ConvXToY(src X) (dst Y)), into (func ConvXIntoY(src *X, dst *Y), keeping unmapped destination fields) or apply (func ApplyXToY(src X, dst *Y), skipping nil and zero source fields)")
+	Generator.Flags.StringVar(&flagNil, "nil", "", "nil and empty slices and maps of the source: allocate (always make), preserve (keep nil) or omitempty (keep nil when empty); when not set, slices and maps of the same type are assigned as they are and others are allocated")
+	Generator.Flags.BoolVar(&flagNilIfZero, "nilIfZero", false, "leave destination pointers nil when the source struct is the zero value")
+	Generator.Flags.StringVar(&flagSliceToScalar, "sliceToScalar", "first", "slice to non-slice conversion: first, last, join (strings) or none; overridden by the tag option `cvt:\",first\"` etc.")
+	Generator.Flags.StringVar(&flagScalarToSlice, "scalarToSlice", "wrap", "non-slice to slice conversion: wrap (slice of one element) or none; overridden by the tag option `cvt:\",wrap\"` etc.")
+	Generator.Flags.StringVar(&flagJoinSep, "joinSep", ",", "separator of join")
+	Generator.Flags.StringVar(&flagTime, "time", "", "convert time.Time and time.Duration to and from integers (unix or unixmilli), float64 seconds and strings; overridden by the tag option `cvt:\",unixmilli\"` etc.")
+	Generator.Flags.BoolVar(&flagText, "text", false, "convert to strings with MarshalText or String, and from strings and []byte with UnmarshalText of the destination pointer")
+	Generator.Flags.StringVar(&flagTimeLayout, "timeLayout", time.RFC3339, "layout of time.Time in strings; overridden by the tag option `cvt:\",layout:2006-01-02\"`")
+	Generator.Flags.BoolVar(&flagGraph, "graph", false, "convert pointers to named types once with a visited map, keeping cycles and shared pointers; only with -mode return")
+	Generator.Flags.BoolVar(&flagDeepCopy, "deepcopy", false, "copy slices, maps, pointers and arrays of identical types instead of assigning them")
+	Generator.Flags.BoolVar(&flagCvtUtil, "cvtutil", false, "convert slices, maps and pointers by calling the generic helpers of github.com/fuji8/gotypeconverter/cvtutil instead of loops; only with -mode return, without -graph")
+	Generator.Flags.Var(&flagCollections, "collections", "also generate converters of collections for each converter of named types: slice ([]X to []Y), pointers ([]*X to []*Y) or map:K (map[K]X to map[K]Y); comma separated")
+	Generator.Flags.StringVar(&flagEnumUnknown, "enumUnknown", "", "destination constant used for unmapped enum values; \"error\" fails on unmapped source constants and returns an error for other values")
+}
+
+// stringsFlag 複数回指定、またはカンマ区切りで指定できるフラグ
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringsFlag) Set(v string) error {
+	for _, e := range strings.Split(v, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			*s = append(*s, e)
+		}
+	}
+	return nil
+}
+
+// defaultsFlag Type.Field=value の形式で、複数回指定できるフラグ
+type defaultsFlag map[string]string
+
+func (d *defaultsFlag) String() string {
+	kvs := make([]string, 0, len(*d))
+	for k, v := range *d {
+		kvs = append(kvs, k+"="+v)
+	}
+	sort.Strings(kvs)
+	return strings.Join(kvs, ",")
+}
+
+func (d *defaultsFlag) Set(v string) error {
+	i := strings.Index(v, "=")
+	if i < 0 || !strings.Contains(v[:i], ".") {
+		return fmt.Errorf("invalid default %q: want Type.Field=value", v)
+	}
+	if *d == nil {
+		*d = map[string]string{}
+	}
+	(*d)[strings.TrimSpace(v[:i])] = strings.TrimSpace(v[i+1:])
+	return nil
+}
+
+// renamesFlag Src=Dst の形式で、複数回またはカンマ区切りで指定できるフラグ
+type renamesFlag map[string]string
+
+func (r *renamesFlag) String() string {
+	kvs := make([]string, 0, len(*r))
+	for k, v := range *r {
+		kvs = append(kvs, k+"="+v)
+	}
+	sort.Strings(kvs)
+	return strings.Join(kvs, ",")
+}
+
+func (r *renamesFlag) Set(v string) error {
+	if *r == nil {
+		*r = map[string]string{}
+	}
+	for _, kv := range strings.Split(v, ",") {
+		i := strings.Index(kv, "=")
+		if i < 0 {
+			return fmt.Errorf("invalid rename %q: want Src=Dst", kv)
+		}
+		(*r)[strings.TrimSpace(kv[:i])] = strings.TrimSpace(kv[i+1:])
+	}
+	return nil
+}
+
+// setOptions フラグの値を analysis に渡す
+func setOptions() error {
+	match, err := ana.ParseMatchMode(flagMatch)
+	if err != nil {
+		return err
+	}
+	ana.Match = match
+	getter, err := ana.ParseGetterMode(flagGetter)
+	if err != nil {
+		return err
+	}
+	ana.Getter = getter
+	private, err := ana.ParsePrivateMode(flagPrivate)
+	if err != nil {
+		return err
+	}
+	ana.Private = private
+	mode, err := ana.ParseConvMode(flagMode)
+	if err != nil {
+		return err
+	}
+	ana.Mode = mode
+	if flagGraph && mode != ana.ModeReturn {
+		return errors.New("-graph can be used only with -mode return")
+	}
+	ana.Graph = flagGraph
+	if (len(splitTypes(flagSrc)) > 1 || len(splitTypes(flagDst)) > 1) && (mode != ana.ModeReturn || flagGraph) {
+		return errors.New("multiple -s or -d can be used only with -mode return, without -graph")
+	}
+	ana.DeepCopy = flagDeepCopy
+	if flagCvtUtil && (mode != ana.ModeReturn || flagGraph) {
+		return errors.New("-cvtutil can be used only with -mode return, without -graph")
+	}
+	ana.CvtUtil = flagCvtUtil
+	ana.Collections = nil
+	for _, s := range flagCollections {
+		c, err := ana.ParseCollection(s)
+		if err != nil {
+			return err
+		}
+		ana.Collections = append(ana.Collections, c)
+	}
+	nilPolicy, err := ana.ParseNilPolicy(flagNil)
+	if err != nil {
+		return err
+	}
+	ana.NilCollection = nilPolicy
+	ana.NilIfZero = flagNilIfZero
+	if ana.SliceToScalar, err = ana.ParseSliceOption(flagSliceToScalar, ana.SliceFirst, ana.SliceLast, ana.SliceJoin, ana.SliceNone); err != nil {
+		return err
+	}
+	if ana.ScalarToSlice, err = ana.ParseSliceOption(flagScalarToSlice, ana.SliceWrap, ana.SliceNone); err != nil {
+		return err
+	}
+	ana.JoinSep = flagJoinSep
+	if ana.Time, err = ana.ParseTimeUnit(flagTime); err != nil {
+		return err
+	}
+	ana.TimeLayout = flagTimeLayout
+	ana.Text = flagText
+	style, err := ana.ParseStyleMode(flagStyle)
+	if err != nil {
+		return err
+	}
+	ana.Style = style
+	ana.PairStyles = map[string]ana.StyleMode{}
+	for pair, v := range flagPairStyle {
+		if !strings.Contains(pair, ":") {
+			return fmt.Errorf("invalid pairStyle %q: want Src:Dst=style", pair)
+		}
+		if ana.PairStyles[pair], err = ana.ParseStyleMode(v); err != nil {
+			return err
+		}
+	}
+	ana.FuncName = nil
+	if flagFuncName != "" {
+		if ana.FuncName, err = ana.ParseNameTemplate("funcName", flagFuncName); err != nil {
+			return err
+		}
+	}
+	visibility, err := ana.ParseVisibilityMode(flagVisibility)
+	if err != nil {
+		return err
+	}
+	ana.Visibility = visibility
+	collision, err := ana.ParseCollisionMode(flagCollision)
+	if err != nil {
+		return err
+	}
+	ana.Collision = collision
+	if ana.MethodTo, err = ana.ParseNameTemplate("methodTo", flagMethodTo); err != nil {
+		return err
+	}
+	if ana.MethodFrom, err = ana.ParseNameTemplate("methodFrom", flagMethodFrom); err != nil {
+		return err
+	}
+	ana.MatchTags = flagMatchTag
+	ana.StripPrefixes = flagStripPrefix
+	ana.StripSuffixes = flagStripSuffix
+	ana.Defaults = flagDefault
+	ana.EnumRenames = flagEnum
+	ana.EnumUnknown = flagEnumUnknown
+	ana.Setter = flagSetter
+	ana.Constructor = flagConstructor
+	return nil
+}
+
+// splitTypes -s の型をカンマで分ける。[] () {} の中のカンマでは分けない。
+func splitTypes(s string) []string {
+	ts := make([]string, 0, 1)
+	depth, start := 0, 0
+	for i, r := range s {
+		switch r {
+		case '[', '(', '{':
+			depth++
+		case ']', ')', '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				ts = append(ts, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(ts, strings.TrimSpace(s[start:]))
 }
 
 func CreateTmpFile(path string) {
 	ops = 0
+	if flagType != "" {
+		flagSrc, flagDst = flagType, flagType
+		flagDeepCopy = true
+	}
 
 	// tmpFilePath = path + "/tmp-001.go"
 	rand.Seed(time.Now().UnixNano())
@@ -61,8 +309,19 @@ func CreateTmpFile(path string) {
 
 	src := fmt.Sprintf("package %s\n", pkg)
 	uniqueFuncName = fmt.Sprintf("unique%03d", rand.Int63n(1e3))
-	src += fmt.Sprintf("func %s(){var (a %s\n b %s\n)\nfmt.Println(a, b)}\n",
-		uniqueFuncName, flagSrc, flagDst)
+	// -s -d の型ごとに a0 a1 ... b0 b1 ...
+	vars := ""
+	args := make([]string, 0)
+	for _, v := range []struct {
+		prefix, types string
+	}{{"a", flagSrc}, {"b", flagDst}} {
+		for i, s := range splitTypes(v.types) {
+			vars += fmt.Sprintf("%s%d %s\n", v.prefix, i, s)
+			args = append(args, fmt.Sprintf("%s%d", v.prefix, i))
+		}
+	}
+	src += fmt.Sprintf("func %s(){var (%s)\nfmt.Println(%s)}\n",
+		uniqueFuncName, vars, strings.Join(args, ", "))
 
 	// goimports do not imports from go.mod
 	res, err := imports.Process(tmpFilePath, []byte(src), &imports.Options{
@@ -73,13 +332,18 @@ func CreateTmpFile(path string) {
 	}
 	err = ioutil.WriteFile(tmpFilePath, res, 0755)
 	if err != nil {
+		// 書きかけのファイルを残さない
+		os.Remove(tmpFilePath)
 		panic(err)
 	}
-
 }
 
 // Init 解析のための一時ファイルを作成する
 func Init() {
+	// gotypeconverter deepcopy -t T は、-deepcopy -nil preserve -t T と同じ
+	if len(os.Args) > 1 && os.Args[1] == "deepcopy" {
+		os.Args = append([]string{os.Args[0], "-deepcopy", "-nil", "preserve"}, os.Args[2:]...)
+	}
 	err := Generator.Flags.Parse(os.Args[1:])
 	if err != nil {
 		panic(err)
@@ -90,6 +354,17 @@ func Init() {
 		os.Exit(0)
 	}
 
+	// -t T も deepcopy と同じく、-nil を指定しなければ preserve
+	if flagType != "" {
+		nilSet := false
+		Generator.Flags.Visit(func(f *flag.Flag) {
+			nilSet = nilSet || f.Name == "nil"
+		})
+		if !nilSet {
+			flagNil = "preserve"
+		}
+	}
+
 	if Generator.Flags.NArg() == 0 {
 		return
 	}
@@ -113,7 +388,8 @@ func run(pass *codegen.Pass) error {
 		os.Remove(tmpFilePath)
 	}()
 
-	var srcAST, dstAST ast.Expr
+	srcNames, dstNames := splitTypes(flagSrc), splitTypes(flagDst)
+	srcASTs, dstASTs := make([]ast.Expr, len(srcNames)), make([]ast.Expr, len(dstNames))
 	existTargeFile := false
 	for _, f := range pass.Files {
 		// TODO read tmp*.go only
@@ -138,11 +414,16 @@ func run(pass *codegen.Pass) error {
 							if !ok {
 								return false
 							}
-							switch s.Names[0].Name {
-							case "a":
-								srcAST = s.Type
-							case "b":
-								dstAST = s.Type
+							name := s.Names[0].Name
+							i, err := strconv.Atoi(name[1:])
+							if err != nil {
+								continue
+							}
+							switch {
+							case name[0] == 'a' && i < len(srcASTs):
+								srcASTs[i] = s.Type
+							case name[0] == 'b' && i < len(dstASTs):
+								dstASTs[i] = s.Type
 							}
 						}
 					}
@@ -161,8 +442,10 @@ func run(pass *codegen.Pass) error {
 		return nil
 	}
 
-	if srcAST == nil || dstAST == nil {
-		return errors.New("-s or -d are invalid")
+	for _, typeAST := range append(srcASTs, dstASTs...) {
+		if typeAST == nil {
+			return errors.New("-s or -d are invalid")
+		}
 	}
 	if atomic.LoadUint64(&ops) != 0 {
 		return nil
@@ -170,11 +453,29 @@ func run(pass *codegen.Pass) error {
 	// ファイルを書くのは、一回のみ
 	atomic.AddUint64(&ops, 1)
 
-	srcType := pass.TypesInfo.TypeOf(srcAST)
-	dstType := pass.TypesInfo.TypeOf(dstAST)
+	if err := setOptions(); err != nil {
+		return err
+	}
 
 	funcMaker := ana.InitFuncMaker(pass.Pkg)
-	funcMaker.MakeFunc(ana.InitType(dstType, flagDst), ana.InitType(srcType, flagSrc))
+	if len(srcASTs) == 1 && len(dstASTs) == 1 {
+		srcType := pass.TypesInfo.TypeOf(srcASTs[0])
+		dstType := pass.TypesInfo.TypeOf(dstASTs[0])
+		funcMaker.MakeFunc(ana.InitType(dstType, flagDst), ana.InitType(srcType, flagSrc))
+	} else {
+		srcTypes := make([]ana.Type, len(srcASTs))
+		for i, srcAST := range srcASTs {
+			srcTypes[i] = ana.InitType(pass.TypesInfo.TypeOf(srcAST), srcNames[i])
+		}
+		dstTypes := make([]ana.Type, len(dstASTs))
+		for i, dstAST := range dstASTs {
+			dstTypes[i] = ana.InitType(pass.TypesInfo.TypeOf(dstAST), dstNames[i])
+		}
+		funcMaker.MakeMultiFunc(dstTypes, srcTypes)
+	}
+	if err := funcMaker.Err(); err != nil {
+		return err
+	}
 
 	if flagOutput == "" {
 		src, err := ui.NoInfoGeneration(funcMaker)
@@ -182,7 +483,7 @@ func run(pass *codegen.Pass) error {
 			return err
 		}
 		pass.Print(src)
-		return nil
+		return writeHelpers(pass, funcMaker, filepath.Dir(tmpFilePath))
 	}
 
 	src, err := ui.FileNameGeneration(funcMaker, flagOutput)
@@ -198,5 +499,88 @@ func run(pass *codegen.Pass) error {
 
 	fmt.Fprint(f, src)
 
+	return writeHelpers(pass, funcMaker, filepath.Dir(flagOutput))
+}
+
+// helperFileName 見えないフィールドを読み書きする関数を書き出すファイル
+const helperFileName = "gotypeconverter_private.go"
+
+// writeHelpers 見えないフィールドを読み書きする関数を、それぞれのパッケージに書き出す。
+// -o が無い場合は、出力先からの相対パスを付けて標準出力に書き出す。
+func writeHelpers(pass *codegen.Pass, fm *ana.FuncMaker, outputDir string) error {
+	helpers := fm.Helpers()
+	pkgs := make([]*types.Package, 0, len(helpers))
+	for pkg := range helpers {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Slice(pkgs, func(i, j int) bool {
+		return pkgs[i].Path() < pkgs[j].Path()
+	})
+
+	for _, pkg := range pkgs {
+		dir, err := pkgDir(pass, pkg, outputDir)
+		if err != nil {
+			return err
+		}
+		if moduleRoot(dir) != moduleRoot(outputDir) {
+			return fmt.Errorf("cannot generate accessors into package %s: it is not in the module of the output package", pkg.Path())
+		}
+
+		fileName := filepath.Join(dir, helperFileName)
+		src, err := ui.HelperGeneration(pkg.Name(), helpers[pkg], fileName)
+		if err != nil {
+			return err
+		}
+
+		if flagOutput == "" {
+			rel, err := filepath.Rel(outputDir, fileName)
+			if err != nil {
+				rel = fileName
+			}
+			pass.Print(fmt.Sprintf("\n// %s\n", filepath.ToSlash(rel)))
+			pass.Print(src)
+			continue
+		}
+		if err := ioutil.WriteFile(fileName, []byte(src), 0644); err != nil {
+			return err
+		}
+	}
 	return nil
 }
+
+// pkgDir パッケージのディレクトリを返す。
+func pkgDir(pass *codegen.Pass, pkg *types.Package, outputDir string) (string, error) {
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		if f := pass.Fset.File(scope.Lookup(name).Pos()); f != nil && filepath.IsAbs(f.Name()) {
+			return filepath.Dir(f.Name()), nil
+		}
+	}
+	bp, err := build.Import(pkg.Path(), outputDir, build.FindOnly)
+	if err != nil {
+		return "", fmt.Errorf("cannot find package %s: %w", pkg.Path(), err)
+	}
+	return bp.Dir, nil
+}
+
+// moduleRoot dir を含むモジュールのディレクトリを返す。モジュールで無い場合は GOROOT, GOPATH のディレクトリを返す。
+func moduleRoot(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(filepath.Join(d, "go.mod")); err == nil {
+			return d
+		}
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+	for _, root := range append([]string{build.Default.GOROOT}, filepath.SplitList(build.Default.GOPATH)...) {
+		if root != "" && strings.HasPrefix(dir, filepath.Join(root, "src")+string(filepath.Separator)) {
+			return root
+		}
+	}
+	return ""
+}
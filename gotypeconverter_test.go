@@ -3,9 +3,14 @@ package gotypeconverter
 import (
 	"flag"
 	"os"
+	"path"
+	"path/filepath"
+	"regexp"
 	"testing"
 
+	"github.com/fuji8/gotypeconverter/analysis"
 	"github.com/gostaticanalysis/codegen/codegentest"
+	"golang.org/x/tools/go/packages"
 )
 
 var flagUpdate bool
@@ -17,10 +22,131 @@ func TestMain(m *testing.M) {
 }
 
 func TestGenerator(t *testing.T) {
-	Generator.Flags.Set("s", "SRC")
-	Generator.Flags.Set("d", "DST")
+	tests := []struct {
+		pkg   string
+		flags map[string]string
+	}{
+		{"a", nil},
+		{"match", map[string]string{"match": "normalize", "matchTag": "json", "strip-prefix": "Event"}},
+		{"a/ignoretags", map[string]string{"s": "MatchSRC", "d": "MatchDST", "matchTag": "json,xml"}},
+		{"values", map[string]string{"default": `DST.Kind="user"`}},
+		{"a/enum", map[string]string{"s": "DBStatus", "d": "Status", "enum": "DBStatusArchived=StatusClosed", "enumUnknown": "StatusUnknown"}},
+		{"enumerror", map[string]string{"enumUnknown": "error"}},
+		{"enumcast", nil},
+		{"getter", map[string]string{"getter": "prefer"}},
+		{"setter", map[string]string{"constructor": "true"}},
+		{"private", map[string]string{"private": "split", "match": "ignorecase"}},
+		{"method", map[string]string{"style": "method", "pairStyle": "db.Tag:Tag=function", "methodFrom": "From{{title .Src.Pkg}}"}},
+		{"name", map[string]string{"s": "Request", "d": "Response", "funcName": "{{.Src.Name}}To{{.Dst.Name}}", "visibility": "unexported"}},
+		{"into", map[string]string{"mode": "into"}},
+		{"apply", map[string]string{"s": "PatchEventRequest", "d": "Event", "mode": "apply"}},
+		{"collection/allocate", map[string]string{"nil": "allocate"}},
+		{"collection/preserve", map[string]string{"nil": "preserve"}},
+		{"collection/omitempty", map[string]string{"nil": "omitempty"}},
+		{"sliceoption", map[string]string{"sliceToScalar": "none", "joinSep": " "}},
+		{"graph", map[string]string{"graph": "true"}},
+		{"recursive", map[string]string{"s": "Thread", "d": "ThreadDST"}},
+		{"deepcopy", map[string]string{"t": "Spec", "nil": "preserve"}},
+		{"merge", map[string]string{"s": "Event,Room,[]User", "d": "EventResponse"}},
+		{"split", map[string]string{"s": "EventRow", "d": "Event,Room"}},
+		{"wrapper", map[string]string{"s": "Event", "d": "EventDST", "collections": "slice,pointers,map:ID"}},
+		{"cvthelper", map[string]string{"cvtutil": "true"}},
+		{"depth", nil},
+		{"lazy", map[string]string{"nilIfZero": "true"}},
+		{"null", nil},
+		{"timeconv", map[string]string{"time": "unix"}},
+		{"textconv", map[string]string{"text": "true"}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.pkg, func(t *testing.T) {
+			resetFlags()
+			defer resetFlags()
+			Generator.Flags.Set("s", "SRC")
+			Generator.Flags.Set("d", "DST")
+			for name, value := range tt.flags {
+				if err := Generator.Flags.Set(name, value); err != nil {
+					t.Fatal(err)
+				}
+			}
 
-	CreateTmpFile(codegentest.TestData() + "/src/a")
-	rs := codegentest.Run(t, codegentest.TestData(), Generator, "a")
-	codegentest.Golden(t, rs, flagUpdate)
+			CreateTmpFile(codegentest.TestData() + "/src/" + tt.pkg)
+			defer os.Remove(tmpFilePath)
+			rs := codegentest.Run(t, codegentest.TestData(), Generator, tt.pkg)
+			codegentest.Golden(t, rs, flagUpdate)
+			for _, r := range rs {
+				if r.Output != nil {
+					compile(t, tt.pkg, r.Output.String())
+				}
+			}
+		})
+	}
+}
+
+// resetFlags 全てのフラグを既定値に戻す
+func resetFlags() {
+	Generator.Flags.VisitAll(func(f *flag.Flag) {
+		switch v := f.Value.(type) {
+		case *stringsFlag:
+			*v = nil
+		case *defaultsFlag:
+			*v = nil
+		case *renamesFlag:
+			*v = nil
+		default:
+			f.Value.Set(f.DefValue)
+		}
+	})
+}
+
+// helperHeaderRe 標準出力で、見えないフィールドを読み書きする関数の前に付くパス
+var helperHeaderRe = regexp.MustCompile(`(?m)^// (\S+/` + regexp.QuoteMeta(helperFileName) + `)\n`)
+
+// compile 生成したコードをパッケージに加えて、コンパイル出来ることを確かめる
+func compile(t *testing.T, pkg, output string) {
+	t.Helper()
+	dir := filepath.Join(codegentest.TestData(), "src", filepath.FromSlash(pkg))
+	bodies := helperHeaderRe.Split(output, -1)
+	overlay := map[string][]byte{
+		filepath.Join(dir, "gotypeconverter_generated.go"): []byte(bodies[0]),
+	}
+	patterns := []string{pkg}
+	for i, m := range helperHeaderRe.FindAllStringSubmatch(output, -1) {
+		overlay[filepath.Join(dir, filepath.FromSlash(m[1]))] = []byte(bodies[i+1])
+		patterns = append(patterns, path.Join(pkg, path.Dir(m[1])))
+	}
+
+	cfg := &packages.Config{
+		Mode:    packages.NeedName | packages.NeedFiles | packages.NeedImports | packages.NeedDeps | packages.NeedTypes | packages.NeedSyntax,
+		Dir:     dir,
+		Env:     append(os.Environ(), "GOPATH="+codegentest.TestData()+string(filepath.ListSeparator)+cvtutilGOPATH(t), "GO111MODULE=off", "GOPROXY=off"),
+		Overlay: overlay,
+	}
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	packages.Visit(pkgs, nil, func(p *packages.Package) {
+		for _, err := range p.Errors {
+			t.Errorf("generated code does not compile: %v", err)
+		}
+	})
+}
+
+// cvtutilGOPATH cvtutil を GOPATH mode で import できるようにした GOPATH
+func cvtutilGOPATH(t *testing.T) string {
+	t.Helper()
+	cvtutil, err := filepath.Abs("cvtutil")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gopath := t.TempDir()
+	dir := filepath.Join(gopath, "src", filepath.FromSlash(analysis.CvtUtilPath))
+	if err := os.MkdirAll(filepath.Dir(dir), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(cvtutil, dir); err != nil {
+		t.Fatal(err)
+	}
+	return gopath
 }
@@ -3,6 +3,7 @@ package a
 import (
 	"a/basic"
 	"a/cast"
+	"a/enum"
 	"a/external"
 	"a/ignoretags"
 	"a/named"
@@ -34,6 +35,7 @@ type SRC struct {
 	structtag  structtag.SRC
 	cast       cast.Foo
 	ignoretags ignoretags.SRC
+	enum       enum.DBStatus
 }
 
 type DST struct {
@@ -49,4 +51,5 @@ type DST struct {
 	structtag  structtag.DST
 	cast       cast.Bar
 	ignoretags ignoretags.DST
+	enum       enum.Status
 }
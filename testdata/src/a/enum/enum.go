@@ -0,0 +1,22 @@
+package enum
+
+// DBStatus db に保存される値
+type DBStatus string
+
+const (
+	DBStatusDraft    DBStatus = "draft"
+	DBStatusOpen     DBStatus = "open"
+	DBStatusOpened   DBStatus = "open" // 同じ値の定数は、名前順で最初のもののみ使う
+	DBStatusClosed   DBStatus = "closed"
+	DBStatusArchived DBStatus = "archived"
+)
+
+// Status ドメインの値
+type Status int
+
+const (
+	StatusUnknown Status = iota
+	StatusDraft
+	StatusOpen
+	StatusClosed
+)
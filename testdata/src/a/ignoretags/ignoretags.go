@@ -7,3 +7,39 @@ type SRC struct {
 type DST struct {
 	Foo int `xml:"FOO"`
 }
+
+// -matchTag json,xml
+
+type JSONSRC struct {
+	UserID int    `json:"user_id"`
+	Name   string `json:"name"`
+	Note   string `json:"note"`
+	// cvt タグに名前が無ければ、json タグの名前を使う
+	Emails []string `json:"mail" cvt:",first"`
+}
+
+// フィールド名が異なっていても、json タグの名前が一致すれば代入する
+type JSONDST struct {
+	ID       int    `json:"user_id"`
+	UserName string `json:"name"`
+	Memo     string `json:"note"`
+	Address  string `json:"mail"`
+}
+
+// json タグが無ければ xml タグの名前を使う
+type XMLDST struct {
+	ID       int    `xml:"user_id"`
+	UserName string `xml:"name"`
+	Memo     string `json:"note" xml:"memo"`
+	Address  string `xml:"mail"`
+}
+
+type MatchSRC struct {
+	JSON JSONSRC
+	XML  JSONSRC
+}
+
+type MatchDST struct {
+	JSON JSONDST
+	XML  XMLDST
+}
@@ -0,0 +1,35 @@
+package apply
+
+type Place struct {
+	Name string
+}
+
+type PlaceRequest struct {
+	Name *string
+}
+
+type PatchEventRequest struct {
+	Name        *string
+	Capacity    *int
+	Description string
+	Tags        []string
+	Place       *PlaceRequest
+	Note        string
+	Open        *bool
+}
+
+type Event struct {
+	ID          int
+	Name        string
+	Capacity    int
+	Description string
+	Tags        []string
+	Place       Place
+	Open        *bool
+
+	note string
+}
+
+func (e *Event) SetNote(note string) {
+	e.note = note
+}
@@ -0,0 +1,18 @@
+package allocate
+
+type ID int
+
+// SRC nil のスライス・マップも、空にする
+type SRC struct {
+	IDs    []int
+	Labels map[string]string
+	Tags   []string
+	Matrix [][]int
+}
+
+type DST struct {
+	IDs    []ID
+	Labels map[string]string
+	Tags   []string
+	Matrix [][]ID
+}
@@ -0,0 +1,18 @@
+package omitempty
+
+type ID int
+
+// SRC 空のスライス・マップは nil のまま
+type SRC struct {
+	IDs    []int
+	Tags   []string
+	Groups map[string][]int
+	Scores map[string]int
+}
+
+type DST struct {
+	IDs    []ID
+	Tags   []string
+	Groups map[string][]ID
+	Scores map[string]int
+}
@@ -0,0 +1,24 @@
+package preserve
+
+type ID int
+
+type Item struct {
+	Name string
+}
+
+type ItemDST struct {
+	Name string
+}
+
+// SRC nil のスライス・マップは nil のまま。空であれば空にする
+type SRC struct {
+	Items  []Item
+	Labels map[string]string
+	Index  map[int]Item
+}
+
+type DST struct {
+	Items  []ItemDST
+	Labels map[string]string
+	Index  map[ID]ItemDST
+}
@@ -0,0 +1,25 @@
+package cvthelper
+
+type Tag struct {
+	Name string
+}
+
+type TagDST struct {
+	Name string
+}
+
+type SRC struct {
+	Tags   []Tag
+	Owner  *Tag
+	Labels map[string]Tag
+	Groups map[string][]Tag
+	Names  []string
+}
+
+type DST struct {
+	Tags   []TagDST
+	Owner  *TagDST
+	Labels map[string]TagDST
+	Groups map[string][]TagDST
+	Names  string
+}
@@ -0,0 +1,28 @@
+package deepcopy
+
+import "time"
+
+type Label struct {
+	Key   string
+	Value string
+}
+
+type Matrix [2][2]int
+
+type Meta struct {
+	Annotations map[string]string
+}
+
+type Spec struct {
+	Name      string
+	Meta      Meta
+	Tags      []string
+	Labels    map[string]Label
+	Owner     *Label
+	Children  []*Spec
+	Points    [3]*int
+	Grid      Matrix
+	Nested    [][]Label
+	CreatedAt time.Time
+	Any       interface{}
+}
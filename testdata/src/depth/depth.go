@@ -0,0 +1,37 @@
+package depth
+
+type ID string
+
+type Tag struct {
+	Name string
+}
+
+type TagDST struct {
+	Name string
+}
+
+type Tags []Tag
+
+type SRC struct {
+	Double   *Tag
+	Single   **Tag
+	Both     **Tag
+	TagList  []Tag
+	Named    Tags
+	Pointers []Tag
+	Deref    []*Tag
+	ID       string
+	Owner    *string
+}
+
+type DST struct {
+	Double   **TagDST
+	Single   *TagDST
+	Both     **TagDST
+	TagList  *[]TagDST
+	Named    *[]TagDST
+	Pointers []*TagDST
+	Deref    []TagDST
+	ID       *ID
+	Owner    **ID
+}
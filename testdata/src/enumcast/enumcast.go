@@ -0,0 +1,35 @@
+package enumcast
+
+// Priority 対応する名前の定数が無いので、キャストする
+type Priority int
+
+const DefaultPriority Priority = 5
+
+type PriorityDST int
+
+const MaxPriorityDST PriorityDST = 10
+
+// Level 名前が対応する定数は switch で変換し、それ以外の値はキャストする
+type Level int
+
+const (
+	LevelLow Level = iota
+	LevelHigh
+)
+
+type Grade int
+
+const (
+	GradeLow Grade = iota + 1
+	GradeHigh
+)
+
+type SRC struct {
+	Priority Priority
+	Level    Level
+}
+
+type DST struct {
+	Priority PriorityDST
+	Level    Grade
+}
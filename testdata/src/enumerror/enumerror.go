@@ -0,0 +1,27 @@
+package enumerror
+
+// DBStatus db に保存される値
+type DBStatus string
+
+const (
+	DBStatusDraft  DBStatus = "draft"
+	DBStatusOpen   DBStatus = "open"
+	DBStatusClosed DBStatus = "closed"
+)
+
+// Status ドメインの値
+type Status int
+
+const (
+	StatusDraft Status = iota + 1
+	StatusOpen
+	StatusClosed
+)
+
+type SRC struct {
+	Status DBStatus
+}
+
+type DST struct {
+	Status Status
+}
@@ -0,0 +1,23 @@
+package getter
+
+import "getter/pb"
+
+type User struct {
+	Name  string
+	Email string
+	Admin bool
+}
+
+type Event struct {
+	ID    int
+	Title string
+	User  User
+}
+
+type SRC struct {
+	Event pb.Event
+}
+
+type DST struct {
+	Event Event
+}
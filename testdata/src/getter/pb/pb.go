@@ -0,0 +1,45 @@
+package pb
+
+// User protobuf で生成されるような構造体
+type User struct {
+	Name  string
+	Email string
+}
+
+func (x *User) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *User) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+// Event メソッドのみを公開する構造体
+type Event struct {
+	id    int
+	title string
+	user  *User
+}
+
+func (e *Event) ID() int {
+	return e.id
+}
+
+func (e *Event) Title() string {
+	return e.title
+}
+
+func (e *Event) GetUser() *User {
+	return e.user
+}
+
+// SetTitle 引数があるので使わない
+func (e *Event) SetTitle(title string) {
+	e.title = title
+}
@@ -0,0 +1,23 @@
+package graph
+
+type Node struct {
+	Name     string
+	Children []*Node
+	Parent   *Node
+}
+
+type NodeDST struct {
+	Name     string
+	Children []*NodeDST
+	Parent   *NodeDST
+}
+
+type SRC struct {
+	Root *Node
+	Tree Node
+}
+
+type DST struct {
+	Root *NodeDST
+	Tree NodeDST
+}
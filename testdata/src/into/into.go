@@ -0,0 +1,29 @@
+package into
+
+type Profile struct {
+	Bio string
+}
+
+// ProfileDST UpdatedAt は対応するフィールドが無いので、Tags の要素でも呼び出し元の値のまま
+type ProfileDST struct {
+	Bio       string
+	UpdatedAt int64
+}
+
+type SRC struct {
+	Name    string
+	Profile *Profile
+	Parent  *Profile
+	Tags    []Profile
+	Links   map[string]Profile
+}
+
+// DST Version は対応するフィールドが無いので、呼び出し元の値のまま
+type DST struct {
+	Name    string
+	Version int
+	Profile *ProfileDST
+	Parent  ProfileDST
+	Tags    []ProfileDST
+	Links   map[string]ProfileDST
+}
@@ -0,0 +1,60 @@
+package lazy
+
+type Profile struct {
+	Nickname *string
+	Avatar   *string
+}
+
+type ProfileDST struct {
+	Nickname string
+	Avatar   string
+}
+
+type Address struct {
+	City string
+}
+
+type AddressDST struct {
+	City string
+}
+
+type Contact struct {
+	Emails []string
+}
+
+type ContactDST struct {
+	Emails []string
+}
+
+type SRC struct {
+	Profile Profile
+	Address Address
+	Contact Contact
+	Info    struct {
+		Nickname *string
+		Avatar   *string
+	}
+	Extra struct {
+		Note *string
+	}
+	Default struct {
+		Note *string
+	}
+}
+
+type DST struct {
+	Profile *ProfileDST
+	Address *AddressDST
+	Contact *ContactDST
+	Info    *struct {
+		Nickname string
+		Avatar   string
+	}
+	Extra *struct {
+		Note string
+	}
+	// 初期値の条件は dst を読むので、先に確保する
+	Default *struct {
+		Note string `cvt:",ifzero:\"none\""`
+	}
+}
@@ -0,0 +1,20 @@
+package match
+
+type SRC struct {
+	ID        int
+	EventID   int
+	UserID    int
+	EventName string
+	GroupID   int    `json:"group_id"`
+	Created   string `json:"created_at"`
+	Ignored   string `json:"-"`
+}
+
+type DST struct {
+	ID        int
+	UserId    int
+	Name      string
+	Group_Id  int
+	CreatedAt string
+	Ignored   string
+}
@@ -0,0 +1,33 @@
+package merge
+
+type Event struct {
+	ID          int
+	Name        string
+	Description string
+}
+
+type Room struct {
+	ID       int
+	Name     string
+	Capacity int
+}
+
+type User struct {
+	ID   int
+	Name string
+}
+
+type UserResponse struct {
+	ID   int
+	Name string
+}
+
+type EventResponse struct {
+	ID          int
+	Name        string
+	Description string
+	RoomID      int    `cvt:"Room:ID"`
+	RoomName    string `cvt:"Room:Name"`
+	Capacity    int
+	Users       []UserResponse
+}
@@ -0,0 +1,14 @@
+package db
+
+type Event struct {
+	ID   int
+	Name string
+}
+
+type Room struct {
+	Name string
+}
+
+type Tag struct {
+	Name string
+}
@@ -0,0 +1,28 @@
+package method
+
+import "method/db"
+
+type Event struct {
+	ID   int
+	Name string
+}
+
+type Room struct {
+	Name string
+}
+
+type Tag struct {
+	Name string
+}
+
+type SRC struct {
+	Event Event
+	Rooms []db.Room
+	Tag   db.Tag
+}
+
+type DST struct {
+	Event db.Event
+	Rooms []Room
+	Tag   Tag
+}
@@ -0,0 +1,6 @@
+package db
+
+type Event struct {
+	ID   int
+	Name string
+}
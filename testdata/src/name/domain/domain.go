@@ -0,0 +1,6 @@
+package domain
+
+type Event struct {
+	ID   int
+	Name string
+}
@@ -0,0 +1,21 @@
+package name
+
+import (
+	"name/db"
+	"name/domain"
+)
+
+type Event struct {
+	ID    int
+	Title string `cvt:"Name"`
+}
+
+type Request struct {
+	Event db.Event
+	Local Event
+}
+
+type Response struct {
+	Event domain.Event
+	Local domain.Event
+}
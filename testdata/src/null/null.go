@@ -0,0 +1,38 @@
+package null
+
+import (
+	"database/sql"
+	"time"
+)
+
+// OptionalInt Valid と値の struct
+type OptionalInt struct {
+	Int   int
+	Valid bool
+}
+
+type Row struct {
+	Name      sql.NullString
+	Age       sql.NullInt64
+	DeletedAt sql.NullTime
+	Nickname  sql.NullString
+	Score     OptionalInt
+}
+
+type User struct {
+	Name      *string
+	Age       int64
+	DeletedAt *time.Time
+	Nickname  string
+	Score     *int
+}
+
+type SRC struct {
+	Row  Row
+	User User
+}
+
+type DST struct {
+	Row  User
+	User Row
+}
@@ -0,0 +1,13 @@
+package db
+
+// Event フィールドを公開しないモデル
+type Event struct {
+	id    int64
+	title string
+	tags  []string
+	Owner string
+}
+
+func NewEvent(id int64, title string, tags []string, owner string) Event {
+	return Event{id: id, title: title, tags: tags, Owner: owner}
+}
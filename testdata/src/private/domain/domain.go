@@ -0,0 +1,13 @@
+package domain
+
+type Event struct {
+	id    int64
+	title string
+	Tags  []string
+	owner string
+}
+
+// SetTitle 見えないフィールドより、メソッドが優先される
+func (e *Event) SetTitle(title string) {
+	e.title = title
+}
@@ -0,0 +1,14 @@
+package private
+
+import (
+	"private/db"
+	"private/domain"
+)
+
+type SRC struct {
+	Event db.Event
+}
+
+type DST struct {
+	Event domain.Event
+}
@@ -0,0 +1,23 @@
+package recursive
+
+type Comment struct {
+	Body    string
+	Replies []Comment
+	Parent  *Comment
+}
+
+type CommentDST struct {
+	Body    string
+	Replies []CommentDST
+	Parent  *CommentDST
+}
+
+type Thread struct {
+	Title    string
+	Comments []Comment
+}
+
+type ThreadDST struct {
+	Title    string
+	Comments []CommentDST
+}
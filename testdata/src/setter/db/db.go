@@ -0,0 +1,8 @@
+package db
+
+// Member name は公開されていないので、NewUser の引数に使えない
+type Member struct {
+	ID    int
+	name  string
+	Email string
+}
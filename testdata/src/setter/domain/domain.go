@@ -0,0 +1,26 @@
+package domain
+
+// User フィールドを公開しない構造体
+type User struct {
+	id    int
+	name  string
+	age   int
+	Email string
+}
+
+func NewUser(id int, name string) *User {
+	return &User{id: id, name: name}
+}
+
+func (u *User) SetName(name string) {
+	u.name = name
+}
+
+func (u *User) SetAge(age int) {
+	u.age = age
+}
+
+// SetEmail 公開されているフィールドがあるので使わない
+func (u *User) SetEmail(email string) {
+	u.Email = email
+}
@@ -0,0 +1,23 @@
+package setter
+
+import (
+	"setter/db"
+	"setter/domain"
+)
+
+type User struct {
+	ID    int
+	Name  string
+	Age   int
+	Email string
+}
+
+type SRC struct {
+	User   User
+	Member db.Member
+}
+
+type DST struct {
+	User   domain.User
+	Member domain.User
+}
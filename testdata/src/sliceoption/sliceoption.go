@@ -0,0 +1,23 @@
+package sliceoption
+
+type Name string
+
+type SRC struct {
+	Ns       []string
+	First    []int
+	Last     []int
+	Words    []string
+	Keywords []string `cvt:",join:\" | \""`
+	Title    Name
+	Email    string
+}
+
+type DST struct {
+	Ns       string // -sliceToScalar none
+	First    int    `cvt:",first"`
+	Last     int    `cvt:",last"`
+	Words    Name   `cvt:",join"`
+	Keywords string
+	Title    []Name
+	Email    []string `cvt:",none"`
+}
@@ -0,0 +1,20 @@
+package split
+
+type EventRow struct {
+	ID       int
+	Title    string
+	RoomID   int    `cvt:"Room:ID"`
+	RoomName string `cvt:"Room:Name"`
+	Capacity int
+}
+
+type Event struct {
+	ID    int
+	Title string
+}
+
+type Room struct {
+	ID       int
+	Name     string
+	Capacity int
+}
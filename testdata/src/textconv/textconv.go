@@ -0,0 +1,66 @@
+package textconv
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type Status int
+
+const (
+	StatusDraft Status = iota
+	StatusPublished
+)
+
+func (s Status) String() string {
+	if s == StatusPublished {
+		return "published"
+	}
+	return "draft"
+}
+
+type Color struct {
+	R, G, B uint8
+}
+
+func (c Color) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)), nil
+}
+
+func (c *Color) UnmarshalText(b []byte) error {
+	_, err := fmt.Sscanf(string(b), "#%02x%02x%02x", &c.R, &c.G, &c.B)
+	return err
+}
+
+type Level int
+
+func (l *Level) UnmarshalText(b []byte) error {
+	switch strings.ToLower(string(b)) {
+	case "low":
+		*l = 0
+	case "high":
+		*l = 1
+	default:
+		return errors.New("unknown level")
+	}
+	return nil
+}
+
+type SRC struct {
+	Status Status
+	Color  Color
+	Raw    Color
+	Level  string
+	Code   []byte
+	Tint   string
+}
+
+type DST struct {
+	Status string
+	Color  string
+	Raw    []byte
+	Level  Level
+	Code   Color
+	Tint   *Color
+}
@@ -0,0 +1,35 @@
+package timeconv
+
+import "time"
+
+type Event struct {
+	Start    time.Time
+	End      time.Time `cvt:",unixmilli"`
+	Date     time.Time `cvt:",layout:2006-01-02"`
+	Created  time.Time
+	Updated  *time.Time
+	Timeout  time.Duration
+	Interval time.Duration
+	TTL      time.Duration
+}
+
+type EventRow struct {
+	Start    int64
+	End      int64
+	Date     string
+	Created  string
+	Updated  *int64
+	Timeout  int32
+	Interval float64
+	TTL      string
+}
+
+type SRC struct {
+	Event Event
+	Rows  []EventRow
+}
+
+type DST struct {
+	Event EventRow
+	Rows  []Event
+}
@@ -0,0 +1,29 @@
+package values
+
+type Status string
+
+const StatusDraft Status = "draft"
+
+type Sub struct {
+	Priority int `cvt:",default:3"`
+}
+
+type SubSRC struct{}
+
+type SRC struct {
+	Name  string
+	Title string
+	Count int
+	Sub   SubSRC
+}
+
+type DST struct {
+	Name    string
+	Status  Status `cvt:",default:\"draft\""`
+	State   Status `cvt:",const:StatusDraft"`
+	Version int    `cvt:",const:1"`
+	Title   string `cvt:",ifzero:\"untitled\""`
+	Count   int    `cvt:",const:2"` // const は変換元より優先する
+	Kind    string // -default DST.Kind="user"
+	Sub     Sub
+}
@@ -0,0 +1,21 @@
+package wrapper
+
+type ID string
+
+type Room struct {
+	Name string
+}
+
+type RoomDST struct {
+	Name string
+}
+
+type Event struct {
+	ID   ID
+	Room Room
+}
+
+type EventDST struct {
+	ID   string
+	Room RoomDST
+}
@@ -11,19 +11,40 @@ import (
 	"sort"
 
 	ana "github.com/fuji8/gotypeconverter/analysis"
+	"golang.org/x/tools/go/ast/astutil"
 	"golang.org/x/tools/imports"
 )
 
 var TmpFilePath = "./generated.go"
 
+// funcKey 関数名。メソッドの場合は、レシーバの型名を付ける。
+func funcKey(fd *ast.FuncDecl) string {
+	if fd.Recv == nil || len(fd.Recv.List) == 0 {
+		return fd.Name.Name
+	}
+	recv := fd.Recv.List[0].Type
+	if star, ok := recv.(*ast.StarExpr); ok {
+		recv = star.X
+	}
+	if ident, ok := recv.(*ast.Ident); ok {
+		return ident.Name + "." + fd.Name.Name
+	}
+	return fd.Name.Name
+}
+
 func sortFunction(data []byte, fileName string) ([]byte, error) {
 	fset := token.NewFileSet()
 	file, err := parser.ParseFile(fset, fileName, data, parser.ParseComments)
 	if err != nil {
 		return nil, err
 	}
+	return formatSorted(fset, file, data)
+}
 
-	// sort function
+// formatSorted 関数をソートして format する。
+// コメントは位置で出力されるので、コメントがある場合は宣言の文字列ごと並べ替える。
+// 宣言の間にある、宣言のものでないコメントは消える。
+func formatSorted(fset *token.FileSet, file *ast.File, data []byte) ([]byte, error) {
 	sort.Slice(file.Decls, func(i, j int) bool {
 		fdi, iok := file.Decls[i].(*ast.FuncDecl)
 		if !iok {
@@ -33,28 +54,95 @@ func sortFunction(data []byte, fileName string) ([]byte, error) {
 		if !jok {
 			return false
 		}
-		return fdi.Name.Name < fdj.Name.Name
+		return funcKey(fdi) < funcKey(fdj)
 	})
 
+	dst := new(bytes.Buffer)
+	if !hasDeclComments(file) {
+		err := format.Node(dst, fset, file)
+		return dst.Bytes(), err
+	}
+
+	tf := fset.File(file.Pos())
+	first := len(data)
+	for _, d := range file.Decls {
+		if start := tf.Offset(declPos(d)); start < first {
+			first = start
+		}
+	}
+	dst.Write(data[:first])
+	for _, d := range file.Decls {
+		dst.Write(data[tf.Offset(declPos(d)):tf.Offset(d.End())])
+		dst.WriteString("\n\n")
+	}
+	return format.Source(dst.Bytes())
+}
+
+// hasDeclComments package 句より後にコメントがあるか
+func hasDeclComments(file *ast.File) bool {
+	for _, c := range file.Comments {
+		if c.Pos() > file.Name.End() {
+			return len(file.Decls) > 0
+		}
+	}
+	return false
+}
+
+// declPos ドキュメントコメントを含めた、宣言の始まり
+func declPos(d ast.Decl) token.Pos {
+	switch d := d.(type) {
+	case *ast.FuncDecl:
+		if d.Doc != nil {
+			return d.Doc.Pos()
+		}
+	case *ast.GenDecl:
+		if d.Doc != nil {
+			return d.Doc.Pos()
+		}
+	}
+	return d.Pos()
+}
+
+// addImports goimports で解決できない import を追加する。
+func addImports(data []byte, fileName string, paths []string) ([]byte, error) {
+	if len(paths) == 0 {
+		return data, nil
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, fileName, data, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		astutil.AddImport(fset, file, path)
+	}
 	dst := new(bytes.Buffer)
 	err = format.Node(dst, fset, file)
 	return dst.Bytes(), err
 }
 
 func NoInfoGeneration(fm *ana.FuncMaker) (string, error) {
+	return noInfoGeneration(fm.Pkg().Name(), fm.WriteBytes(), TmpFilePath, fm.Imports()...)
+}
+
+func noInfoGeneration(pkgName string, body []byte, fileName string, paths ...string) (string, error) {
 	buf := &bytes.Buffer{}
 
 	fmt.Fprintf(buf, "// Code generated by gotypeconverter; DO NOT EDIT.\n")
-	fmt.Fprintf(buf, "package %s\n", fm.Pkg().Name())
+	fmt.Fprintf(buf, "package %s\n", pkgName)
 
-	buf.Write(fm.WriteBytes())
+	buf.Write(body)
 
-	sortedData, err := sortFunction(buf.Bytes(), TmpFilePath)
+	sortedData, err := sortFunction(buf.Bytes(), fileName)
+	if err != nil {
+		return "", err
+	}
+	sortedData, err = addImports(sortedData, fileName, paths)
 	if err != nil {
 		return "", err
 	}
 
-	importedData, err := imports.Process(TmpFilePath, sortedData, &imports.Options{
+	importedData, err := imports.Process(fileName, sortedData, &imports.Options{
 		Fragment: true,
 		Comments: true,
 	})
@@ -67,12 +155,22 @@ func NoInfoGeneration(fm *ana.FuncMaker) (string, error) {
 // FileNameGeneration 新規の関数を追加、同名の関数を置き換え、既存の関数は変更せず、
 // ソートした結果を返します。
 func FileNameGeneration(fm *ana.FuncMaker, outputFilename string) (string, error) {
+	return fileNameGeneration(fm.Pkg().Name(), fm.WriteBytes(), outputFilename, TmpFilePath, fm.Imports()...)
+}
+
+// HelperGeneration 見えないフィールドを読み書きする関数を、FileNameGeneration と同様に
+// fileName に追加した結果を返します。
+func HelperGeneration(pkgName string, body []byte, fileName string) (string, error) {
+	return fileNameGeneration(pkgName, body, fileName, fileName)
+}
+
+func fileNameGeneration(pkgName string, body []byte, outputFilename, tmpFilePath string, paths ...string) (string, error) {
 	fileData, err := ioutil.ReadFile(outputFilename)
 	if err != nil {
-		return NoInfoGeneration(fm)
+		return noInfoGeneration(pkgName, body, tmpFilePath, paths...)
 	}
 
-	output := append(fileData, fm.WriteBytes()...)
+	output := append(fileData, body...)
 	fset := token.NewFileSet()
 	file, err := parser.ParseFile(fset, outputFilename, output, parser.ParseComments)
 	if err != nil {
@@ -83,13 +181,13 @@ func FileNameGeneration(fm *ana.FuncMaker, outputFilename string) (string, error
 	funcDeclMap := make(map[string]*ast.FuncDecl)
 	for _, d := range file.Decls {
 		if fd, ok := d.(*ast.FuncDecl); ok {
-			funcDeclMap[fd.Name.Name] = fd
+			funcDeclMap[funcKey(fd)] = fd
 		}
 	}
 	newDecls := make([]ast.Decl, 0)
 	for _, d := range file.Decls {
 		if fd, ok := d.(*ast.FuncDecl); ok {
-			if _, ok := funcDeclMap[fd.Name.Name]; ok {
+			if _, ok := funcDeclMap[funcKey(fd)]; ok {
 				continue
 			}
 		}
@@ -101,26 +199,15 @@ func FileNameGeneration(fm *ana.FuncMaker, outputFilename string) (string, error
 	}
 	file.Decls = newDecls
 
-	// sort function
-	sort.Slice(file.Decls, func(i, j int) bool {
-		fdi, iok := file.Decls[i].(*ast.FuncDecl)
-		if !iok {
-			return true
-		}
-		fdj, jok := file.Decls[j].(*ast.FuncDecl)
-		if !jok {
-			return false
-		}
-		return fdi.Name.Name < fdj.Name.Name
-	})
-
-	dst := new(bytes.Buffer)
-	err = format.Node(dst, fset, file)
+	sortedData, err := formatSorted(fset, file, output)
+	if err != nil {
+		return "", err
+	}
+	sortedData, err = addImports(sortedData, outputFilename, paths)
 	if err != nil {
 		return "", err
 	}
 
-	sortedData := dst.Bytes()
 	importedData, err := imports.Process(outputFilename, sortedData, &imports.Options{
 		Fragment: true,
 		Comments: true,